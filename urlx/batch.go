@@ -0,0 +1,167 @@
+package urlx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var ErrBatchSkipped = errors.New("batch: skipped")
+
+// BatchResult 一个请求的结果
+type BatchResult struct {
+	Index int   // 请求在列表中的位置
+	Err   error // 处理出错，没有执行时为 ErrBatchSkipped
+}
+
+// BatchError 批量请求中有请求出错
+type BatchError struct {
+	Errors []error // 按请求顺序的错误，成功的为 nil
+	First  error   // 最先发生的错误
+	Failed int     // 出错的请求数
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("batch: %d of %d requests failed, first: %v", e.Failed, len(e.Errors), e.First)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.First
+}
+
+type batchOptions struct {
+	failFast bool
+	interval time.Duration
+}
+
+// BatchOption 批量请求选项
+type BatchOption = func(*batchOptions)
+
+// FailFast 有请求出错时取消其余的请求，默认执行全部请求并收集错误
+func FailFast() BatchOption {
+	return func(o *batchOptions) { o.failFast = true }
+}
+
+// BatchInterval 相邻两个请求开始的最小间隔，用于限制批量请求的速率，默认不限制
+func BatchInterval(d time.Duration) BatchOption {
+	return func(o *batchOptions) { o.interval = d }
+}
+
+type batchIndexKey struct{}
+
+// BatchIndex 批量请求中当前请求的位置，可以在 Process 中通过 resp.Request.Context() 取得
+func BatchIndex(ctx context.Context) (int, bool) {
+	i, ok := ctx.Value(batchIndexKey{}).(int)
+	return i, ok
+}
+
+// Batch 以最多 concurrency 个并发执行请求，每个响应以 fn 处理，concurrency 不大于 0 时不限制
+//
+// 请求以 ctx 复制后执行，原请求不变；每个请求使用自己的客户端和中间件，熔断等中间件照常生效，
+// 请求的速率以 BatchInterval 限制。有请求出错时返回 *BatchError，其中按顺序列出每个请求的错误
+func Batch(ctx context.Context, reqs []*Request, concurrency int, fn Process, options ...BatchOption) error {
+	be := &BatchError{Errors: make([]error, len(reqs))}
+	for r := range batchStream(ctx, reqs, concurrency, fn, nil, options...) {
+		if r.Err != nil {
+			be.Errors[r.Index] = r.Err
+			be.Failed++
+			if be.First == nil && !errors.Is(r.Err, ErrBatchSkipped) {
+				be.First = r.Err
+			}
+		}
+	}
+	if be.Failed == 0 {
+		return nil
+	}
+	if be.First == nil {
+		for _, err := range be.Errors {
+			if err != nil {
+				be.First = err
+				break
+			}
+		}
+	}
+	return be
+}
+
+// BatchStream 同 Batch，按完成的顺序从通道返回每个请求的结果，全部完成后关闭通道
+//
+// 不再读取结果时取消 ctx，未取走的结果被丢弃，执行中的请求结束后退出并关闭通道
+func BatchStream(ctx context.Context, reqs []*Request, concurrency int, fn Process, options ...BatchOption) <-chan BatchResult {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return batchStream(ctx, reqs, concurrency, fn, ctx.Done(), options...)
+}
+
+// batchStream done 关闭后不再等待读取结果，为 nil 时每个结果都会送达
+func batchStream(ctx context.Context, reqs []*Request, concurrency int, fn Process, done <-chan struct{}, options ...BatchOption) <-chan BatchResult {
+	var opts batchOptions
+	for _, apply := range options {
+		apply(&opts)
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if concurrency <= 0 || concurrency > len(reqs) {
+		concurrency = len(reqs)
+	}
+
+	results := make(chan BatchResult, concurrency)
+	ctx, cancel := context.WithCancel(ctx)
+	indexes := make(chan int)
+	send := func(r BatchResult) bool {
+		select {
+		case results <- r:
+			return true
+		case <-done:
+			return false
+		}
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				err := reqs[i].WithContext(context.WithValue(ctx, batchIndexKey{}, i)).Process(fn)
+				if err != nil && opts.failFast {
+					cancel()
+				}
+				send(BatchResult{Index: i, Err: err})
+			}
+		}()
+	}
+
+	go func() {
+		defer close(results)
+		defer cancel()
+		for i := range reqs {
+			if i > 0 && opts.interval > 0 {
+				select {
+				case <-time.After(opts.interval):
+				case <-ctx.Done():
+				}
+			}
+			if ctx.Err() == nil {
+				select {
+				case indexes <- i:
+					continue
+				case <-ctx.Done():
+				}
+			}
+			for ; i < len(reqs); i++ {
+				if !send(BatchResult{Index: i, Err: fmt.Errorf("%w: %v", ErrBatchSkipped, ctx.Err())}) {
+					break
+				}
+			}
+			break
+		}
+		close(indexes)
+		wg.Wait()
+	}()
+	return results
+}
@@ -0,0 +1,101 @@
+package urlx
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBatch(t *testing.T) {
+	var inflight, peak int32
+	addr, closer := mockHTTPServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inflight, 1)
+		defer atomic.AddInt32(&inflight, -1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		if r.URL.Path == "/fail" {
+			rw.WriteHeader(http.StatusInternalServerError)
+		}
+		_, _ = rw.Write([]byte(r.URL.Path))
+	}))
+	defer closer()
+
+	paths := []string{"/0", "/1", "/fail", "/3", "/4", "/5", "/fail", "/7"}
+	reqs := make([]*Request, len(paths))
+	for i, path := range paths {
+		reqs[i] = Default(nil).Url(addr + path)
+	}
+
+	got := make([]string, len(reqs))
+	process := func(resp *http.Response, body io.ReadCloser) error {
+		i, _ := BatchIndex(resp.Request.Context())
+		data, _ := io.ReadAll(body)
+		got[i] = string(data)
+		if resp.StatusCode != http.StatusOK {
+			return errors.New(resp.Status)
+		}
+		return nil
+	}
+
+	err := Batch(context.Background(), reqs, 3, process)
+	var be *BatchError
+	eq(t, [][2]any{
+		{errors.As(err, &be), true},
+		{be.Failed, 2},
+		{be.Errors[0], nil},
+		{be.Errors[2] != nil, true},
+		{be.Errors[6] != nil, true},
+		{got[7], "/7"},
+		{atomic.LoadInt32(&peak) <= 3, true},
+	})
+
+	err = Batch(context.Background(), reqs, 1, process, FailFast())
+	eq(t, [][2]any{
+		{errors.As(err, &be), true},
+		{be.First.Error(), "500 Internal Server Error"},
+		{be.Errors[1], nil},
+		{errors.Is(be.Errors[3], ErrBatchSkipped), true},
+		{errors.Is(be.Errors[7], ErrBatchSkipped), true},
+	})
+
+	var count int
+	for r := range BatchStream(nil, reqs[:2], 0, nil) {
+		eq(t, [][2]any{{r.Err, nil}, {r.Index < 2, true}})
+		count++
+	}
+	eq(t, [][2]any{{count, 2}, {Batch(nil, nil, 4, nil), nil}})
+
+	// 按间隔开始请求
+	var mu sync.Mutex
+	var starts []time.Time
+	interval, closeInterval := mockHTTPServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		starts = append(starts, time.Now())
+		mu.Unlock()
+	}))
+	defer closeInterval()
+	err = Batch(context.Background(), []*Request{Default(nil).Url(interval), Default(nil).Url(interval), Default(nil).Url(interval)}, 3, nil, BatchInterval(30*time.Millisecond))
+	eq(t, [][2]any{{err, nil}, {len(starts), 3}, {starts[2].Sub(starts[0]) >= 60*time.Millisecond, true}})
+
+	// 取消后不再读取，工作协程丢弃结果并关闭通道
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := BatchStream(ctx, reqs, 2, nil)
+	<-stream
+	cancel()
+	time.Sleep(100 * time.Millisecond)
+	count = 1
+	for range stream {
+		count++
+	}
+	eq(t, [][2]any{{count < len(reqs), true}})
+}
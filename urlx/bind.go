@@ -0,0 +1,309 @@
+package urlx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/google/go-querystring/query"
+)
+
+var (
+	ErrBind   = errors.New("bind: invalid api definition")
+	ErrStatus = errors.New("response: unexpected status")
+)
+
+// StatusError 响应状态码不是 2xx，errors.Is(err, ErrStatus) 为真
+type StatusError struct {
+	StatusCode int
+	Status     string
+	Body       []byte // 响应内容，最多 64KB
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrStatus, e.Status)
+}
+
+func (e *StatusError) Unwrap() error {
+	return ErrStatus
+}
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// Bind 为接口定义结构体中的函数字段生成实现，api 为结构体指针，每个请求以 options 初始化
+//
+// 函数字段以 urlx 标签声明方法和路径，args 标签按顺序声明参数的用途，首个参数可以是 context.Context:
+//
+//	type GitHub struct {
+//		Issues func(ctx context.Context, owner, repo, state string) ([]Issue, error) `urlx:"GET /repos/{owner}/{repo}/issues" args:"owner,repo,query:state"`
+//		Create func(ctx context.Context, owner, repo string, in *Issue) (*Issue, error) `urlx:"POST /repos/{owner}/{repo}/issues" args:"owner,repo,body"`
+//	}
+//
+// 参数用途:
+//   - name 或 path:name 替换路径中的 {name}
+//   - query:name 作为 Query 参数，query 不带名称时以结构体或 map 整体编码
+//   - header:Name 作为请求头
+//   - body 以 JSON 提交，form 以表单提交
+//
+// 返回值最后一个必须是 error，前面可以有一个结果，以 JSON 解码，[]byte 和 string 为原始内容。
+// 响应状态码不是 2xx 时返回 *StatusError
+func Bind(api any, base string, options ...Option) error {
+	rv := reflect.ValueOf(api)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("%w: %T is not a pointer to struct", ErrBind, api)
+	}
+	rv = rv.Elem()
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		spec, ok := field.Tag.Lookup("urlx")
+		if !ok || field.Type.Kind() != reflect.Func || !rv.Field(i).CanSet() {
+			continue
+		}
+		ep, err := parseEndpoint(field.Type, spec, field.Tag.Get("args"))
+		if err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrBind, field.Name, err)
+		}
+		ep.base, ep.options = strings.TrimRight(base, "/"), options
+		rv.Field(i).Set(reflect.MakeFunc(field.Type, ep.call))
+	}
+	return nil
+}
+
+type argKind int
+
+const (
+	argPath argKind = iota
+	argQuery
+	argHeader
+	argBody
+	argForm
+)
+
+type endpointArg struct {
+	kind argKind
+	name string
+}
+
+// endpoint 一个函数字段对应的请求
+type endpoint struct {
+	method  string
+	path    string
+	withCtx bool
+	args    []endpointArg
+	result  reflect.Type // 结果类型，没有时为 nil
+
+	base    string
+	options []Option
+}
+
+func parseEndpoint(fn reflect.Type, spec, args string) (*endpoint, error) {
+	ep := &endpoint{}
+
+	parts := strings.Fields(spec)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("urlx tag %q, want \"METHOD /path\"", spec)
+	}
+	ep.method, ep.path = strings.ToUpper(parts[0]), parts[1]
+
+	switch fn.NumOut() {
+	case 2:
+		ep.result = fn.Out(0)
+		fallthrough
+	case 1:
+		if fn.Out(fn.NumOut()-1) != errorType {
+			return nil, errors.New("last result must be error")
+		}
+	default:
+		return nil, errors.New("want (error) or (T, error) results")
+	}
+
+	in := fn.NumIn()
+	if in > 0 && fn.In(0) == contextType {
+		ep.withCtx = true
+		in--
+	}
+	if fn.IsVariadic() {
+		return nil, errors.New("variadic func not supported")
+	}
+
+	if args != "" {
+		for _, arg := range strings.Split(args, ",") {
+			kind, name := argPath, strings.TrimSpace(arg)
+			if i := strings.IndexByte(name, ':'); i >= 0 {
+				kind, name = -1, strings.TrimSpace(name[i+1:])
+				switch strings.TrimSpace(arg[:strings.IndexByte(arg, ':')]) {
+				case "path":
+					kind = argPath
+				case "query":
+					kind = argQuery
+				case "header":
+					kind = argHeader
+				}
+			} else if name == "query" {
+				kind, name = argQuery, ""
+			} else if name == "body" {
+				kind = argBody
+			} else if name == "form" {
+				kind = argForm
+			}
+			switch {
+			case kind < 0:
+				return nil, fmt.Errorf("unknown arg %q", arg)
+			case kind == argPath && !strings.Contains(ep.path, "{"+name+"}"):
+				return nil, fmt.Errorf("path has no {%s}", name)
+			case kind == argHeader && name == "":
+				return nil, fmt.Errorf("header arg %q has no name", arg)
+			}
+			ep.args = append(ep.args, endpointArg{kind: kind, name: name})
+		}
+	}
+	if len(ep.args) != in {
+		return nil, fmt.Errorf("args tag declares %d params, func has %d", len(ep.args), in)
+	}
+	return ep, nil
+}
+
+func (ep *endpoint) call(in []reflect.Value) []reflect.Value {
+	ctx := context.Background()
+	if ep.withCtx {
+		if c, _ := in[0].Interface().(context.Context); c != nil {
+			ctx = c
+		}
+		in = in[1:]
+	}
+
+	var result reflect.Value
+	var out any
+	if ep.result != nil {
+		if ep.result.Kind() == reflect.Ptr {
+			result = reflect.New(ep.result.Elem())
+			out = result.Interface()
+		} else {
+			result = reflect.New(ep.result)
+			out = result.Interface()
+		}
+	}
+
+	err := ep.request(ctx, in).Process(func(resp *http.Response, body io.ReadCloser) error {
+		return decodeResult(resp, body, out)
+	})
+
+	var results []reflect.Value
+	if ep.result != nil {
+		switch {
+		case err != nil:
+			results = append(results, reflect.Zero(ep.result))
+		case ep.result.Kind() == reflect.Ptr:
+			results = append(results, result)
+		default:
+			results = append(results, result.Elem())
+		}
+	}
+	errValue := reflect.Zero(errorType)
+	if err != nil {
+		errValue = reflect.ValueOf(err)
+	}
+	return append(results, errValue)
+}
+
+// request 按参数构造请求
+func (ep *endpoint) request(ctx context.Context, in []reflect.Value) *Request {
+	c := Default(ctx).With(ep.options...).Method(ep.method).HeaderWith(Accept("application/json"))
+
+	path, queries := ep.path, url.Values{}
+	for i, arg := range ep.args {
+		v := in[i].Interface()
+		switch arg.kind {
+		case argPath:
+			path = strings.Replace(path, "{"+arg.name+"}", url.PathEscape(fmt.Sprint(v)), -1)
+		case argQuery:
+			if arg.name != "" {
+				if !isZero(in[i]) {
+					queries.Add(arg.name, fmt.Sprint(v))
+				}
+				continue
+			}
+			values, err := queryValues(v)
+			if err != nil {
+				return c.SendBody(func() (string, io.Reader, error) { return "", nil, err })
+			}
+			for k, vs := range values {
+				queries[k] = append(queries[k], vs...)
+			}
+		case argHeader:
+			if !isZero(in[i]) {
+				c.HeaderWith(HeaderSet(arg.name, fmt.Sprint(v)))
+			}
+		case argBody:
+			c.SendJSON(v)
+		case argForm:
+			c.SendForm(v)
+		}
+	}
+
+	c.Url(ep.base + path)
+	if len(queries) > 0 {
+		c.Query(queries.Encode())
+	}
+	return c
+}
+
+func queryValues(v any) (url.Values, error) {
+	switch o := v.(type) {
+	case url.Values:
+		return o, nil
+	case map[string]string:
+		values := url.Values{}
+		for k, v := range o {
+			values.Set(k, v)
+		}
+		return values, nil
+	}
+	return query.Values(v)
+}
+
+func isZero(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map:
+		return v.IsNil()
+	case reflect.String:
+		return v.Len() == 0
+	}
+	return false
+}
+
+// decodeResult 检查状态码并解码结果
+func decodeResult(resp *http.Response, body io.ReadCloser, out any) error {
+	defer body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		data, _ := io.ReadAll(io.LimitReader(body, 64<<10))
+		return &StatusError{StatusCode: resp.StatusCode, Status: resp.Status, Body: data}
+	}
+
+	switch o := out.(type) {
+	case nil:
+		return nil
+	case *[]byte:
+		data, err := io.ReadAll(body)
+		*o = data
+		return err
+	case *string:
+		data, err := io.ReadAll(body)
+		*o = string(data)
+		return err
+	}
+
+	if err := JSON(out)(resp, body); err != nil && err != io.EOF {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package urlx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/goccy/go-json"
+)
+
+type bindIssue struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	State  string `json:"state,omitempty"`
+}
+
+type bindListOptions struct {
+	Page    int `url:"page,omitempty"`
+	PerPage int `url:"per_page,omitempty"`
+}
+
+type bindAPI struct {
+	Issues  func(ctx context.Context, owner, repo, state string) ([]bindIssue, error)        `urlx:"GET /repos/{owner}/{repo}/issues" args:"owner,repo,query:state"`
+	Page    func(opts bindListOptions) (string, error)                                       `urlx:"GET /page" args:"query"`
+	Create  func(ctx context.Context, repo, token string, in *bindIssue) (*bindIssue, error) `urlx:"POST /repos/{repo}/issues" args:"repo,header:Authorization,body"`
+	Missing func(ctx context.Context) error                                                  `urlx:"DELETE /missing"`
+}
+
+func TestBind(t *testing.T) {
+	addr, closer := mockHTTPServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.RawPath == "/api/repos/a%2Fb/c/issues":
+			_ = json.NewEncoder(rw).Encode([]bindIssue{{Number: 1, Title: r.URL.RawPath + "|" + r.URL.Query().Get("state")}})
+		case r.URL.Path == "/api/page":
+			_, _ = rw.Write([]byte(r.URL.RawQuery))
+		case r.Method == http.MethodPost:
+			var in bindIssue
+			_ = json.NewDecoder(r.Body).Decode(&in)
+			in.Number, in.State = 2, r.Header.Get("Authorization")
+			_ = json.NewEncoder(rw).Encode(in)
+		default:
+			http.Error(rw, "not found", http.StatusNotFound)
+		}
+	}))
+	defer closer()
+
+	var api bindAPI
+	if err := Bind(&api, addr+"/api/"); err != nil {
+		t.Fatal(err)
+	}
+
+	issues, err := api.Issues(context.Background(), "a/b", "c", "open")
+	eq(t, [][2]any{{err, nil}, {len(issues), 1}, {issues[0].Title, "/api/repos/a%2Fb/c/issues|open"}})
+
+	page, err := api.Page(bindListOptions{Page: 2, PerPage: 10})
+	eq(t, [][2]any{{err, nil}, {page, "page=2&per_page=10"}})
+
+	created, err := api.Create(context.Background(), "r", "token x", &bindIssue{Title: "hello"})
+	eq(t, [][2]any{{err, nil}, {created.Number, 2}, {created.Title, "hello"}, {created.State, "token x"}})
+
+	err = api.Missing(context.Background())
+	var se *StatusError
+	eq(t, [][2]any{{errors.Is(err, ErrStatus), true}, {errors.As(err, &se), true}, {se.StatusCode, http.StatusNotFound}})
+
+	var bad struct {
+		Get func(id string) error `urlx:"GET /items/{key}" args:"id"`
+	}
+	eq(t, [][2]any{{errors.Is(Bind(&bad, addr), ErrBind), true}})
+}
@@ -0,0 +1,250 @@
+package urlx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+var ErrCircuitOpen = errors.New("circuit: open")
+
+// CircuitState 熔断器状态
+type CircuitState int
+
+const (
+	CircuitClosed   CircuitState = iota // 关闭，正常放行
+	CircuitOpen                         // 打开，直接失败
+	CircuitHalfOpen                     // 半开，放行少量试探请求
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// MarshalText 实现 encoding.TextMarshaler
+func (s CircuitState) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// BreakerOptions 熔断器设置，零值字段使用默认值
+type BreakerOptions struct {
+	Window       time.Duration                             // 统计窗口，默认 10s
+	Buckets      int                                       // 窗口分桶数，默认 10，多于窗口的纳秒数时取窗口的纳秒数
+	MinRequests  int                                       // 窗口内请求数达到后才判断失败率，默认 10
+	FailureRatio float64                                   // 失败率阈值，默认 0.5
+	Cooldown     time.Duration                             // 打开后等待多久进入半开，默认 30s
+	HalfOpenMax  int                                       // 半开时的试探请求数，全部成功后关闭，默认 1
+	IsFailure    func(resp *http.Response, err error) bool // 是否失败，默认为出错或 5xx，调用方取消的请求不计入
+}
+
+// Breaker 按主机熔断
+type Breaker struct {
+	opts BreakerOptions
+
+	mu       sync.Mutex
+	circuits map[string]*circuit
+}
+
+type circuit struct {
+	state    CircuitState
+	openedAt time.Time
+	trials   int // 半开时已放行的试探请求
+	passed   int // 半开时成功的试探请求
+	buckets  []bucket
+}
+
+type bucket struct {
+	start             time.Time
+	success, failures int
+}
+
+// NewBreaker 创建熔断器
+func NewBreaker(opts BreakerOptions) *Breaker {
+	if opts.Window <= 0 {
+		opts.Window = 10 * time.Second
+	}
+	if opts.Buckets <= 0 {
+		opts.Buckets = 10
+	}
+	if time.Duration(opts.Buckets) > opts.Window {
+		opts.Buckets = int(opts.Window)
+	}
+	if opts.MinRequests <= 0 {
+		opts.MinRequests = 10
+	}
+	if opts.FailureRatio <= 0 {
+		opts.FailureRatio = 0.5
+	}
+	if opts.Cooldown <= 0 {
+		opts.Cooldown = 30 * time.Second
+	}
+	if opts.HalfOpenMax <= 0 {
+		opts.HalfOpenMax = 1
+	}
+	if opts.IsFailure == nil {
+		opts.IsFailure = func(resp *http.Response, err error) bool {
+			return err != nil || (resp != nil && resp.StatusCode >= 500)
+		}
+	}
+	return &Breaker{opts: opts, circuits: map[string]*circuit{}}
+}
+
+// CircuitBreaker 以熔断器保护请求的选项，熔断时返回 ErrCircuitOpen 且不再重试
+func CircuitBreaker(b *Breaker) Option {
+	return Use(b.Middleware())
+}
+
+// Middleware 熔断中间件
+func (b *Breaker) Middleware() Middleware {
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			host := req.URL.Host
+			if !b.allow(host, time.Now()) {
+				return nil, fmt.Errorf("%w: %s", ErrCircuitOpen, host)
+			}
+			resp, err := next.Do(req)
+			if err != nil && (errors.Is(err, context.Canceled) || errors.Is(req.Context().Err(), context.Canceled)) {
+				// 调用方取消，不代表主机的状态，归还试探名额
+				b.release(host)
+				return resp, err
+			}
+			b.record(host, time.Now(), b.opts.IsFailure(resp, err))
+			return resp, err
+		})
+	}
+}
+
+// State 主机当前的熔断状态
+func (b *Breaker) State(host string) CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if c, ok := b.circuits[host]; ok {
+		return b.current(c, time.Now())
+	}
+	return CircuitClosed
+}
+
+// States 所有主机的熔断状态
+func (b *Breaker) States() map[string]CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	states := make(map[string]CircuitState, len(b.circuits))
+	for host, c := range b.circuits {
+		states[host] = b.current(c, now)
+	}
+	return states
+}
+
+// ServeHTTP 以 JSON 输出所有主机的熔断状态，有主机熔断时状态码为 503，用于健康检查
+func (b *Breaker) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	states := b.States()
+	status := http.StatusOK
+	for _, state := range states {
+		if state == CircuitOpen {
+			status = http.StatusServiceUnavailable
+		}
+	}
+
+	rw.Header().Set(HeaderContentType, "application/json; charset=utf-8")
+	rw.WriteHeader(status)
+	_ = json.NewEncoder(rw).Encode(states)
+}
+
+// current 打开超过冷却时间后进入半开
+func (b *Breaker) current(c *circuit, now time.Time) CircuitState {
+	if c.state == CircuitOpen && now.Sub(c.openedAt) >= b.opts.Cooldown {
+		c.state, c.trials, c.passed = CircuitHalfOpen, 0, 0
+	}
+	return c.state
+}
+
+func (b *Breaker) circuit(host string) *circuit {
+	c, ok := b.circuits[host]
+	if !ok {
+		c = &circuit{buckets: make([]bucket, b.opts.Buckets)}
+		b.circuits[host] = c
+	}
+	return c
+}
+
+func (b *Breaker) allow(host string, now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c := b.circuit(host)
+	switch b.current(c, now) {
+	case CircuitOpen:
+		return false
+	case CircuitHalfOpen:
+		if c.trials >= b.opts.HalfOpenMax {
+			return false
+		}
+		c.trials++
+	}
+	return true
+}
+
+// release 不计结果，半开时归还试探名额
+func (b *Breaker) release(host string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if c := b.circuit(host); c.state == CircuitHalfOpen && c.trials > 0 {
+		c.trials--
+	}
+}
+
+func (b *Breaker) record(host string, now time.Time, failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c := b.circuit(host)
+
+	switch c.state {
+	case CircuitHalfOpen:
+		if failed {
+			c.state, c.openedAt = CircuitOpen, now
+			return
+		}
+		if c.passed++; c.passed >= b.opts.HalfOpenMax {
+			c.state = CircuitClosed
+			c.buckets = make([]bucket, b.opts.Buckets)
+		}
+		return
+	case CircuitOpen:
+		return
+	}
+
+	width := b.opts.Window / time.Duration(b.opts.Buckets)
+	start := now.Truncate(width)
+	bk := &c.buckets[int((start.UnixNano()/int64(width))%int64(len(c.buckets)))]
+	if !bk.start.Equal(start) {
+		*bk = bucket{start: start}
+	}
+	if failed {
+		bk.failures++
+	} else {
+		bk.success++
+	}
+
+	var total, failures int
+	for _, x := range c.buckets {
+		if now.Sub(x.start) < b.opts.Window {
+			total += x.success + x.failures
+			failures += x.failures
+		}
+	}
+	if total >= b.opts.MinRequests && float64(failures)/float64(total) >= b.opts.FailureRatio {
+		c.state, c.openedAt = CircuitOpen, now
+	}
+}
@@ -0,0 +1,83 @@
+package urlx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	var hits int32
+	var healthy atomic.Value
+	healthy.Store(false)
+	addr, closer := mockHTTPServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		if !healthy.Load().(bool) {
+			rw.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer closer()
+	u, _ := url.Parse(addr)
+
+	b := NewBreaker(BreakerOptions{MinRequests: 3, Cooldown: 50 * time.Millisecond})
+	for i := 0; i < 5; i++ {
+		_ = Default(nil).Url(addr).With(CircuitBreaker(b)).Process(nil)
+	}
+	err := Default(nil).Url(addr).With(CircuitBreaker(b)).TryAt(time.Millisecond, time.Millisecond).Process(nil)
+	eq(t, [][2]any{
+		{errors.Is(err, ErrCircuitOpen), true},
+		{atomic.LoadInt32(&hits), int32(3)},
+		{b.State(u.Host), CircuitOpen},
+	})
+
+	time.Sleep(60 * time.Millisecond)
+	eq(t, [][2]any{{b.State(u.Host), CircuitHalfOpen}})
+	healthy.Store(true)
+	err = Default(nil).Url(addr).With(CircuitBreaker(b)).Process(nil)
+	eq(t, [][2]any{{err, nil}, {b.State(u.Host), CircuitClosed}})
+}
+
+func TestBreakerCanceledTrial(t *testing.T) {
+	var healthy atomic.Value
+	healthy.Store(false)
+	addr, closer := mockHTTPServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(100 * time.Millisecond)
+		}
+		if !healthy.Load().(bool) {
+			rw.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer closer()
+	u, _ := url.Parse(addr)
+
+	// 窗口小于分桶数纳秒时不会除零
+	b := NewBreaker(BreakerOptions{Window: 5, Buckets: 10, MinRequests: 1, Cooldown: 20 * time.Millisecond})
+	_ = Default(nil).Url(addr).With(CircuitBreaker(b)).Process(nil)
+	b = NewBreaker(BreakerOptions{MinRequests: 1, Cooldown: 20 * time.Millisecond})
+	_ = Default(nil).Url(addr).With(CircuitBreaker(b)).Process(nil)
+	eq(t, [][2]any{{b.State(u.Host), CircuitOpen}})
+
+	// 半开时取消的试探请求不关闭熔断，名额归还给下一个试探
+	time.Sleep(30 * time.Millisecond)
+	healthy.Store(true)
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	err := Default(ctx).Url(addr + "/slow").With(CircuitBreaker(b)).Process(nil)
+	eq(t, [][2]any{{errors.Is(err, context.Canceled), true}, {b.State(u.Host), CircuitHalfOpen}})
+
+	err = Default(nil).Url(addr).With(CircuitBreaker(b)).Process(nil)
+	eq(t, [][2]any{{err, nil}, {b.State(u.Host), CircuitClosed}})
+}
+
+func TestBreakerBucketIndex(t *testing.T) {
+	// 桶序号超过 int32 时，32 位平台上不能截断成负数
+	b := NewBreaker(BreakerOptions{Window: time.Second, Buckets: 10, MinRequests: 1})
+	now := time.Unix(0, (1<<31)*int64(100*time.Millisecond))
+	b.record("h", now, true)
+	eq(t, [][2]any{{b.current(b.circuit("h"), now), CircuitOpen}})
+}
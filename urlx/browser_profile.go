@@ -0,0 +1,307 @@
+package urlx
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	HeaderSecChUa         = "Sec-CH-UA"
+	HeaderSecChUaMobile   = "Sec-CH-UA-Mobile"
+	HeaderSecChUaPlatform = "Sec-CH-UA-Platform"
+	HeaderSecFetchSite    = "Sec-Fetch-Site"
+	HeaderSecFetchMode    = "Sec-Fetch-Mode"
+	HeaderSecFetchUser    = "Sec-Fetch-User"
+	HeaderSecFetchDest    = "Sec-Fetch-Dest"
+	HeaderUpgradeInsecure = "Upgrade-Insecure-Requests"
+)
+
+// BrowserProfile 浏览器特征，UA 和与之匹配的一组请求头。
+//
+// 不包含请求头顺序：标准库 Transport 写出请求头时按字母排序，无法按浏览器的顺序发送
+type BrowserProfile struct {
+	Name            string // 名称，注册表中唯一
+	Mobile          bool   // 是否移动端
+	UserAgent       string // User-Agent
+	SecChUa         string // Sec-CH-UA，Firefox 和 Safari 不发送
+	SecChUaPlatform string // Sec-CH-UA-Platform
+	Accept          string // Accept
+	AcceptLanguage  string // Accept-Language
+	AcceptEncoding  string // Accept-Encoding
+}
+
+// Header 将浏览器特征应用到请求头，作为一次顶层导航
+func (p BrowserProfile) Header() HeaderOption {
+	return func(headers http.Header) {
+		set := func(key, value string) {
+			if value != "" {
+				headers.Set(key, value)
+			}
+		}
+		set(HeaderUserAgent, p.UserAgent)
+		set(HeaderAccept, p.Accept)
+		set(HeaderAcceptLanguage, p.AcceptLanguage)
+		set(HeaderAcceptEncoding, p.AcceptEncoding)
+		if p.SecChUa != "" {
+			headers.Set(HeaderSecChUa, p.SecChUa)
+			headers.Set(HeaderSecChUaMobile, map[bool]string{true: "?1", false: "?0"}[p.Mobile])
+			set(HeaderSecChUaPlatform, p.SecChUaPlatform)
+		}
+		headers.Set(HeaderSecFetchSite, "none")
+		headers.Set(HeaderSecFetchMode, "navigate")
+		headers.Set(HeaderSecFetchUser, "?1")
+		headers.Set(HeaderSecFetchDest, "document")
+		headers.Set(HeaderUpgradeInsecure, "1")
+	}
+}
+
+const (
+	chromeAccept  = "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,image/apng,*/*;q=0.8,application/signed-exchange;v=b3;q=0.7"
+	firefoxAccept = "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"
+	chromeChUa    = `"Chromium";v="141", "Not?A_Brand";v="8", "Google Chrome";v="141"`
+	edgeChUa      = `"Chromium";v="141", "Not?A_Brand";v="8", "Microsoft Edge";v="141"`
+	chineseLang   = "zh-CN,zh;q=0.9,en;q=0.8"
+)
+
+// 内置的浏览器特征
+var (
+	ChromeWindows = BrowserProfile{
+		Name:            "chrome-windows",
+		UserAgent:       "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/141.0.0.0 Safari/537.36",
+		SecChUa:         chromeChUa,
+		SecChUaPlatform: `"Windows"`,
+		Accept:          chromeAccept,
+		AcceptLanguage:  chineseLang,
+		AcceptEncoding:  "gzip, deflate, br, zstd",
+	}
+
+	ChromeMac = BrowserProfile{
+		Name:            "chrome-mac",
+		UserAgent:       "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/141.0.0.0 Safari/537.36",
+		SecChUa:         chromeChUa,
+		SecChUaPlatform: `"macOS"`,
+		Accept:          chromeAccept,
+		AcceptLanguage:  chineseLang,
+		AcceptEncoding:  "gzip, deflate, br, zstd",
+	}
+
+	EdgeWindows = BrowserProfile{
+		Name:            "edge-windows",
+		UserAgent:       "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/141.0.0.0 Safari/537.36 Edg/141.0.0.0",
+		SecChUa:         edgeChUa,
+		SecChUaPlatform: `"Windows"`,
+		Accept:          chromeAccept,
+		AcceptLanguage:  chineseLang,
+		AcceptEncoding:  "gzip, deflate, br, zstd",
+	}
+
+	EdgeMac = BrowserProfile{
+		Name:            "edge-mac",
+		UserAgent:       "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/141.0.0.0 Safari/537.36 Edg/141.0.0.0",
+		SecChUa:         edgeChUa,
+		SecChUaPlatform: `"macOS"`,
+		Accept:          chromeAccept,
+		AcceptLanguage:  chineseLang,
+		AcceptEncoding:  "gzip, deflate, br, zstd",
+	}
+
+	FirefoxWindows = BrowserProfile{
+		Name:           "firefox-windows",
+		UserAgent:      "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:143.0) Gecko/20100101 Firefox/143.0",
+		Accept:         firefoxAccept,
+		AcceptLanguage: "zh-CN,zh;q=0.8,zh-TW;q=0.7,zh-HK;q=0.5,en-US;q=0.3,en;q=0.2",
+		AcceptEncoding: "gzip, deflate, br, zstd",
+	}
+
+	SafariMac = BrowserProfile{
+		Name:           "safari-mac",
+		UserAgent:      "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/26.0 Safari/605.1.15",
+		Accept:         firefoxAccept,
+		AcceptLanguage: "zh-CN,zh-Hans;q=0.9",
+		AcceptEncoding: "gzip, deflate, br",
+	}
+
+	ChromeAndroid = BrowserProfile{
+		Name:            "chrome-android",
+		Mobile:          true,
+		UserAgent:       "Mozilla/5.0 (Linux; Android 10; K) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/141.0.0.0 Mobile Safari/537.36",
+		SecChUa:         chromeChUa,
+		SecChUaPlatform: `"Android"`,
+		Accept:          chromeAccept,
+		AcceptLanguage:  chineseLang,
+		AcceptEncoding:  "gzip, deflate, br, zstd",
+	}
+
+	SafariIPhone = BrowserProfile{
+		Name:           "safari-iphone",
+		Mobile:         true,
+		UserAgent:      "Mozilla/5.0 (iPhone; CPU iPhone OS 18_6 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/26.0 Mobile/15E148 Safari/604.1",
+		Accept:         firefoxAccept,
+		AcceptLanguage: "zh-CN,zh-Hans;q=0.9",
+		AcceptEncoding: "gzip, deflate, br",
+	}
+)
+
+var profiles = struct {
+	sync.RWMutex
+	list []BrowserProfile
+}{list: []BrowserProfile{ChromeWindows, ChromeMac, EdgeWindows, EdgeMac, FirefoxWindows, SafariMac, ChromeAndroid, SafariIPhone}}
+
+// RegisterProfile 注册浏览器特征，同名覆盖
+func RegisterProfile(p BrowserProfile) {
+	profiles.Lock()
+	defer profiles.Unlock()
+	for i, o := range profiles.list {
+		if o.Name == p.Name {
+			profiles.list[i] = p
+			return
+		}
+	}
+	profiles.list = append(profiles.list, p)
+}
+
+// LookupProfile 按名称查找浏览器特征
+func LookupProfile(name string) (BrowserProfile, bool) {
+	profiles.RLock()
+	defer profiles.RUnlock()
+	for _, p := range profiles.list {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return BrowserProfile{}, false
+}
+
+// DesktopProfiles 已注册的桌面端浏览器特征
+func DesktopProfiles() []BrowserProfile {
+	return filterProfiles(false)
+}
+
+// MobileProfiles 已注册的移动端浏览器特征
+func MobileProfiles() []BrowserProfile {
+	return filterProfiles(true)
+}
+
+// RandomProfile 从候选中随机选取，不传则从所有已注册中选取
+func RandomProfile(candidates ...BrowserProfile) BrowserProfile {
+	if len(candidates) == 0 {
+		profiles.RLock()
+		candidates = profiles.list
+		defer profiles.RUnlock()
+	}
+	if len(candidates) == 0 {
+		return BrowserProfile{}
+	}
+	return candidates[rand.Intn(len(candidates))]
+}
+
+func filterProfiles(mobile bool) (r []BrowserProfile) {
+	profiles.RLock()
+	defer profiles.RUnlock()
+	for _, p := range profiles.list {
+		if p.Mobile == mobile {
+			r = append(r, p)
+		}
+	}
+	return
+}
+
+// Browser 浏览器会话，在多次导航之间共享浏览器特征和引用地址
+type Browser struct {
+	candidates []BrowserProfile
+	rotate     bool
+	referer    bool
+
+	mu      sync.Mutex
+	current BrowserProfile
+	last    string
+}
+
+// Browse 以候选的浏览器特征创建浏览器会话，不传则从所有已注册的特征中选取
+func Browse(candidates ...BrowserProfile) *Browser {
+	return &Browser{candidates: candidates, current: RandomProfile(candidates...)}
+}
+
+// Rotate 每次请求随机更换浏览器特征，默认整个会话使用同一个
+func (b *Browser) Rotate(rotate bool) *Browser {
+	b.rotate = rotate
+	return b
+}
+
+// ChainReferer 自动将上一次导航的最终地址作为下一次请求的 Referer
+func (b *Browser) ChainReferer(enabled bool) *Browser {
+	b.referer = enabled
+	return b
+}
+
+// Profile 当前使用的浏览器特征
+func (b *Browser) Profile() BrowserProfile {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.current
+}
+
+// New 以当前会话的浏览器特征开始一个请求
+func (b *Browser) New(ctx context.Context) *Request {
+	b.mu.Lock()
+	if b.rotate {
+		b.current = RandomProfile(b.candidates...)
+	}
+	profile := b.current
+	b.mu.Unlock()
+
+	return New(ctx).
+		ProcessWith(CharsetDecode, DecompressionBody, b.record).
+		HeaderWith(profile.Header(), NoCache).
+		With(b.chain).
+		TryAt(time.Millisecond*100, time.Millisecond*500, time.Millisecond*800)
+}
+
+// chain 设置引用地址和对应的 Sec-Fetch-Site
+func (b *Browser) chain(c *Request) error {
+	if !b.referer {
+		return nil
+	}
+	b.mu.Lock()
+	last := b.last
+	b.mu.Unlock()
+	if last == "" {
+		return nil
+	}
+
+	site := "cross-site"
+	if ref, err := url.Parse(last); err == nil {
+		if target, err := url.Parse(c.url); err == nil && sameOrigin(ref, target) {
+			site = "same-origin"
+		}
+	}
+	c.HeaderWith(func(headers http.Header) {
+		if headers.Get(HeaderReferer) == "" {
+			headers.Set(HeaderReferer, last)
+			headers.Set(HeaderSecFetchSite, site)
+		}
+	})
+	return nil
+}
+
+// record 记录导航的最终地址
+func (b *Browser) record(next Process) Process {
+	return func(resp *http.Response, body io.ReadCloser) error {
+		if b.referer && resp.Request != nil {
+			b.mu.Lock()
+			b.last = resp.Request.URL.String()
+			b.mu.Unlock()
+		}
+		return next(resp, body)
+	}
+}
+
+func sameOrigin(a, b *url.URL) bool {
+	return a.Scheme == b.Scheme && strings.EqualFold(a.Host, b.Host)
+}
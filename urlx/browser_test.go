@@ -0,0 +1,32 @@
+package urlx
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestBrowserChainReferer(t *testing.T) {
+	var got []http.Header
+	addr, closer := mockHTTPServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Clone())
+	}))
+	defer closer()
+
+	b := Browse(FirefoxWindows).ChainReferer(true)
+	for _, path := range []string{"/a", "/b"} {
+		if err := b.New(context.TODO()).Url(addr + path).Process(nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	eq(t, [][2]any{
+		{len(got), 2},
+		{got[0].Get(HeaderUserAgent), FirefoxWindows.UserAgent},
+		{got[0].Get(HeaderReferer), ""},
+		{got[0].Get(HeaderSecFetchSite), "none"},
+		{got[0].Get(HeaderSecChUa), ""},
+		{got[1].Get(HeaderReferer), addr + "/a"},
+		{got[1].Get(HeaderSecFetchSite), "same-origin"},
+	})
+}
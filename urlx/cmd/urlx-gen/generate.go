@@ -0,0 +1,802 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// imports 生成代码可能用到的包
+var imports = map[string]string{
+	"base64":  "encoding/base64",
+	"context": "context",
+	"fmt":     "fmt",
+	"http":    "net/http",
+	"io":      "io",
+	"json":    "encoding/json",
+	"strings": "strings",
+	"time":    "time",
+	"url":     "net/url",
+	"urlx":    "github.com/cnk3x/go/urlx",
+}
+
+var methodConsts = map[string]string{
+	"GET": "urlx.MethodGet", "PUT": "urlx.MethodPut", "POST": "urlx.MethodPost", "DELETE": "urlx.MethodDelete",
+	"OPTIONS": "urlx.MethodOptions", "HEAD": "urlx.MethodHead", "PATCH": "urlx.MethodPatch", "TRACE": "urlx.MethodTrace",
+}
+
+// 生成的方法中使用的局部变量
+var locals = map[string]bool{"c": true, "ctx": true, "params": true, "body": true, "contentType": true, "query": true, "req": true, "out": true, "err": true, "resp": true}
+
+// Generator 由 OpenAPI 文档生成客户端代码
+type Generator struct {
+	doc     *Document
+	pkg     string
+	client  string
+	used    map[string]bool   // 用到的包
+	names   map[string]bool   // 已声明的类型
+	structs map[string]bool   // 已声明的结构体类型
+	decls   []*bytes.Buffer   // 类型声明
+	schemas map[string]string // 组件名称到类型名称
+}
+
+// Generate 生成 pkg 包的客户端代码，client 为客户端类型名称
+func Generate(doc *Document, pkg, client string) ([]byte, error) {
+	if client == "" {
+		client = "Client"
+	}
+	g := &Generator{
+		doc:     doc,
+		pkg:     pkg,
+		client:  client,
+		used:    map[string]bool{},
+		names:   map[string]bool{client: true},
+		structs: map[string]bool{},
+		schemas: map[string]string{},
+	}
+
+	// 先登记组件名称，引用可以出现在声明之前
+	components := make([]string, 0, len(doc.Components.Schemas))
+	for name := range doc.Components.Schemas {
+		components = append(components, name)
+	}
+	sort.Strings(components)
+	for _, name := range components {
+		typeName := g.reserve(goName(name))
+		g.schemas[name] = typeName
+		if isStruct(doc.Components.Schemas[name]) {
+			g.structs[typeName] = true
+		}
+	}
+	for _, name := range components {
+		if err := g.declare(g.schemas[name], doc.Components.Schemas[name]); err != nil {
+			return nil, fmt.Errorf("schema %s: %w", name, err)
+		}
+	}
+
+	var code bytes.Buffer
+	if err := g.genClient(&code); err != nil {
+		return nil, err
+	}
+	if err := g.genOperations(&code); err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by urlx-gen. DO NOT EDIT.\n\n")
+	if doc.Info.Title != "" {
+		fmt.Fprintf(&out, "// Package %s %s 的客户端", pkg, doc.Info.Title)
+		if doc.Info.Version != "" {
+			fmt.Fprintf(&out, "，版本 %s", doc.Info.Version)
+		}
+		out.WriteString("\n")
+	}
+	fmt.Fprintf(&out, "package %s\n\nimport (\n", pkg)
+	paths := make([]string, 0, len(g.used))
+	for name := range g.used {
+		paths = append(paths, imports[name])
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		if strings.Contains(path, ".") {
+			continue
+		}
+		fmt.Fprintf(&out, "\t%q\n", path)
+	}
+	out.WriteString("\n")
+	for _, path := range paths {
+		if strings.Contains(path, ".") {
+			fmt.Fprintf(&out, "\t%q\n", path)
+		}
+	}
+	out.WriteString(")\n\n")
+	for _, decl := range g.decls {
+		out.Write(decl.Bytes())
+		out.WriteString("\n")
+	}
+	out.Write(code.Bytes())
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return out.Bytes(), fmt.Errorf("format generated code: %w", err)
+	}
+	return src, nil
+}
+
+func (g *Generator) use(pkg string) string {
+	g.used[pkg] = true
+	return pkg
+}
+
+// reserve 登记类型名称，重名时加上序号
+func (g *Generator) reserve(name string) string {
+	unique := name
+	for i := 2; g.names[unique]; i++ {
+		unique = name + strconv.Itoa(i)
+	}
+	g.names[unique] = true
+	return unique
+}
+
+func isStruct(s *Schema) bool {
+	return s != nil && s.Ref == "" && (len(s.Properties) > 0 || len(s.AllOf) > 1 || (s.Type == "object" && s.AdditionalProperties == nil && len(s.AllOf) == 0))
+}
+
+func comment(w *bytes.Buffer, indent, name, text string) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return
+	}
+	for i, line := range strings.Split(text, "\n") {
+		if i == 0 && name != "" {
+			line = name + " " + line
+		}
+		fmt.Fprintf(w, "%s// %s\n", indent, strings.TrimRight(line, " \t"))
+	}
+}
+
+// typeOf 结构对应的类型，内联的对象以 hint 为名称声明
+func (g *Generator) typeOf(s *Schema, hint string) (string, error) {
+	if s == nil {
+		return "interface{}", nil
+	}
+	if s.Ref != "" {
+		name, err := refName(s.Ref, "schemas")
+		if err != nil {
+			return "", err
+		}
+		typeName, ok := g.schemas[name]
+		if !ok {
+			return "", fmt.Errorf("%w: %s not found", ErrSpec, s.Ref)
+		}
+		return typeName, nil
+	}
+	if len(s.AllOf) == 1 && len(s.Properties) == 0 {
+		return g.typeOf(s.AllOf[0], hint)
+	}
+	if len(s.OneOf) > 0 || len(s.AnyOf) > 0 {
+		return g.use("json") + ".RawMessage", nil
+	}
+	if isStruct(s) {
+		name := g.reserve(hint)
+		g.structs[name] = true
+		return name, g.declare(name, s)
+	}
+
+	switch s.Type {
+	case "string":
+		switch s.Format {
+		case "date-time":
+			return g.use("time") + ".Time", nil
+		case "byte":
+			return "[]byte", nil
+		}
+		return "string", nil
+	case "integer":
+		switch s.Format {
+		case "int32":
+			return "int32", nil
+		case "int64":
+			return "int64", nil
+		}
+		return "int", nil
+	case "number":
+		if s.Format == "float" {
+			return "float32", nil
+		}
+		return "float64", nil
+	case "boolean":
+		return "bool", nil
+	case "array":
+		elem, err := g.typeOf(s.Items, hint+"Item")
+		return "[]" + elem, err
+	case "object":
+		if s.AdditionalProperties != nil && s.AdditionalProperties.Schema != nil {
+			elem, err := g.typeOf(s.AdditionalProperties.Schema, hint+"Value")
+			return "map[string]" + elem, err
+		}
+		return "map[string]interface{}", nil
+	}
+	return "interface{}", nil
+}
+
+// declare 声明命名类型
+func (g *Generator) declare(name string, s *Schema) error {
+	w := &bytes.Buffer{}
+	g.decls = append(g.decls, w)
+	comment(w, "", name, s.Description)
+
+	if !isStruct(s) {
+		if s.Type == "string" && len(s.Enum) > 0 && s.Format == "" {
+			fmt.Fprintf(w, "type %s string\n\n", name)
+			w.WriteString("const (\n")
+			for _, v := range s.Enum {
+				if str, ok := v.(string); ok {
+					fmt.Fprintf(w, "\t%s %s = %q\n", g.reserve(name+goName(str)), name, str)
+				}
+			}
+			w.WriteString(")\n")
+			return nil
+		}
+		typ, err := g.typeOf(s, name+"Item")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "type %s %s\n", name, typ)
+		return nil
+	}
+
+	fmt.Fprintf(w, "type %s struct {\n", name)
+	properties := map[string]*Schema{}
+	required := map[string]bool{}
+	parts := append([]*Schema{s}, s.AllOf...)
+	for _, part := range parts {
+		if part.Ref != "" {
+			embed, err := g.typeOf(part, "")
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "\t%s\n", embed)
+			continue
+		}
+		for prop, ps := range part.Properties {
+			properties[prop] = ps
+		}
+		for _, prop := range part.Required {
+			required[prop] = true
+		}
+	}
+
+	props := make([]string, 0, len(properties))
+	for prop := range properties {
+		props = append(props, prop)
+	}
+	sort.Strings(props)
+	for _, prop := range props {
+		ps := properties[prop]
+		fieldName := goName(prop)
+		typ, err := g.typeOf(ps, name+fieldName)
+		if err != nil {
+			return fmt.Errorf("property %s: %w", prop, err)
+		}
+		tag := prop
+		if !required[prop] {
+			tag += ",omitempty"
+		}
+		if (!required[prop] || ps.Nullable) && pointable(typ) {
+			typ = "*" + typ
+		}
+		comment(w, "\t", "", ps.Description)
+		fmt.Fprintf(w, "\t%s %s `json:%q`\n", fieldName, typ, tag)
+	}
+	w.WriteString("}\n")
+	return nil
+}
+
+// pointable 可选时使用指针的类型，切片、映射和接口本身可以为空
+func pointable(typ string) bool {
+	return !strings.HasPrefix(typ, "[]") && !strings.HasPrefix(typ, "map[") && typ != "interface{}" && typ != "json.RawMessage"
+}
+
+// authScheme 客户端中的认证设置
+type authScheme struct {
+	name   string // 文档中的名称
+	field  string // 客户端字段
+	scheme *SecurityScheme
+}
+
+func (g *Generator) authSchemes() []authScheme {
+	names := make([]string, 0, len(g.doc.Components.SecuritySchemes))
+	for name := range g.doc.Components.SecuritySchemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	schemes := make([]authScheme, 0, len(names))
+	for _, name := range names {
+		schemes = append(schemes, authScheme{name: name, field: goName(name), scheme: g.doc.Components.SecuritySchemes[name]})
+	}
+	return schemes
+}
+
+func (g *Generator) genClient(w *bytes.Buffer) error {
+	if len(g.doc.Servers) > 0 {
+		w.WriteString("// DefaultServer 文档中的第一个服务地址\n")
+		fmt.Fprintf(w, "const DefaultServer = %q\n\n", g.doc.Servers[0].URL)
+	}
+
+	fmt.Fprintf(w, "// %s 接口客户端，认证信息为空时不发送\n", g.client)
+	fmt.Fprintf(w, "type %s struct {\n", g.client)
+	w.WriteString("\tBaseURL string        // 服务地址\n")
+	fmt.Fprintf(w, "\tOptions []%s.Option // 每个请求的选项\n", g.use("urlx"))
+	for _, auth := range g.authSchemes() {
+		s := auth.scheme
+		w.WriteString("\n")
+		comment(w, "\t", "", s.Description)
+		switch {
+		case s.Type == "http" && strings.EqualFold(s.Scheme, "basic"):
+			fmt.Fprintf(w, "\t%sUsername string // %s Basic 认证\n", auth.field, auth.name)
+			fmt.Fprintf(w, "\t%sPassword string // %s Basic 认证\n", auth.field, auth.name)
+		case s.Type == "apiKey":
+			fmt.Fprintf(w, "\t%s string // %s API Key，%s %s\n", auth.field, auth.name, s.In, s.Name)
+		default:
+			fmt.Fprintf(w, "\t%s string // %s Bearer Token\n", auth.field, auth.name)
+		}
+	}
+	w.WriteString("}\n\n")
+
+	fmt.Fprintf(w, "// New%s 创建客户端\n", g.client)
+	fmt.Fprintf(w, "func New%s(baseURL string, options ...urlx.Option) *%s {\n", g.client, g.client)
+	fmt.Fprintf(w, "\treturn &%s{BaseURL: baseURL, Options: options}\n}\n\n", g.client)
+
+	fmt.Fprintf(w, "func (c *%s) request(ctx %s.Context, method, path string) *urlx.Request {\n", g.client, g.use("context"))
+	fmt.Fprintf(w, "\treturn urlx.Default(ctx).With(c.Options...).Method(method).Url(%s.TrimRight(c.BaseURL, \"/\") + path).HeaderWith(urlx.Accept(\"application/json\"))\n}\n", g.use("strings"))
+
+	for _, auth := range g.authSchemes() {
+		s := auth.scheme
+		fmt.Fprintf(w, "\nfunc (c *%s) has%s() bool {\n", g.client, auth.field)
+		if s.Type == "http" && strings.EqualFold(s.Scheme, "basic") {
+			fmt.Fprintf(w, "\treturn c.%[1]sUsername != \"\" || c.%[1]sPassword != \"\"\n}\n", auth.field)
+		} else {
+			fmt.Fprintf(w, "\treturn c.%s != \"\"\n}\n", auth.field)
+		}
+
+		fmt.Fprintf(w, "\nfunc (c *%s) auth%s(req *urlx.Request, query %s.Values) {\n", g.client, auth.field, g.use("url"))
+		switch {
+		case s.Type == "http" && strings.EqualFold(s.Scheme, "basic"):
+			fmt.Fprintf(w, "\treq.HeaderWith(urlx.HeaderSet(\"Authorization\", \"Basic \"+%s.StdEncoding.EncodeToString([]byte(c.%[2]sUsername+\":\"+c.%[2]sPassword))))\n", g.use("base64"), auth.field)
+		case s.Type == "apiKey":
+			switch s.In {
+			case "query":
+				fmt.Fprintf(w, "\tquery.Set(%q, c.%s)\n", s.Name, auth.field)
+			case "cookie":
+				fmt.Fprintf(w, "\treq.HeaderWith(func(h %s.Header) { h.Add(\"Cookie\", %q+c.%s) })\n", g.use("http"), s.Name+"=", auth.field)
+			default:
+				fmt.Fprintf(w, "\treq.HeaderWith(urlx.HeaderSet(%q, c.%s))\n", s.Name, auth.field)
+			}
+		default:
+			fmt.Fprintf(w, "\treq.HeaderWith(urlx.HeaderSet(\"Authorization\", \"Bearer \"+c.%s))\n", auth.field)
+		}
+		w.WriteString("}\n")
+	}
+	return nil
+}
+
+// operation 生成方法需要的信息
+type operation struct {
+	name      string
+	method    string
+	path      string
+	op        *Operation
+	pathArgs  []*Parameter
+	params    []*Parameter // query、header 和 cookie 参数
+	body      *RequestBody
+	security  [][]string // 可选的认证方式，每一项中的方案同时使用
+	responses []string
+}
+
+func (g *Generator) genOperations(w *bytes.Buffer) error {
+	paths := make([]string, 0, len(g.doc.Paths))
+	for path := range g.doc.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		item := g.doc.Paths[path]
+		methods, ops := item.Operations()
+		for i, op := range ops {
+			o, err := g.operation(path, methods[i], item, op)
+			if err != nil {
+				return fmt.Errorf("%s %s: %w", methods[i], path, err)
+			}
+			if err = g.genOperation(w, o); err != nil {
+				return fmt.Errorf("%s %s: %w", methods[i], path, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (g *Generator) operation(path, method string, item *PathItem, op *Operation) (*operation, error) {
+	o := &operation{method: method, path: path, op: op}
+	if op.OperationID != "" {
+		o.name = goName(op.OperationID)
+	} else {
+		o.name = goName(strings.ToLower(method) + " " + path)
+	}
+
+	// 操作的参数覆盖路径的同名参数
+	var params []*Parameter
+	index := map[string]int{}
+	for _, list := range [][]*Parameter{item.Parameters, op.Parameters} {
+		for _, p := range list {
+			p, err := g.doc.parameter(p)
+			if err != nil {
+				return nil, err
+			}
+			key := p.In + ":" + p.Name
+			if i, ok := index[key]; ok {
+				params[i] = p
+				continue
+			}
+			index[key] = len(params)
+			params = append(params, p)
+		}
+	}
+	for _, p := range params {
+		if p.In == "path" {
+			o.pathArgs = append(o.pathArgs, p)
+		} else {
+			o.params = append(o.params, p)
+		}
+	}
+	// 路径参数按在路径中出现的顺序
+	sort.SliceStable(o.pathArgs, func(i, j int) bool {
+		return strings.Index(path, "{"+o.pathArgs[i].Name+"}") < strings.Index(path, "{"+o.pathArgs[j].Name+"}")
+	})
+
+	var err error
+	if o.body, err = g.doc.requestBody(op.RequestBody); err != nil {
+		return nil, err
+	}
+
+	security := g.doc.Security
+	if op.Security != nil {
+		security = *op.Security
+	}
+	for _, requirement := range security {
+		// 空的一项表示可以不认证，不需要生成
+		if len(requirement) == 0 {
+			continue
+		}
+		names := make([]string, 0, len(requirement))
+		for name := range requirement {
+			if _, ok := g.doc.Components.SecuritySchemes[name]; !ok {
+				return nil, fmt.Errorf("%w: security scheme %s not found", ErrSpec, name)
+			}
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		o.security = append(o.security, names)
+	}
+
+	for code := range op.Responses {
+		o.responses = append(o.responses, code)
+	}
+	sort.Slice(o.responses, func(i, j int) bool { return statusOrder(o.responses[i]) < statusOrder(o.responses[j]) })
+	return o, nil
+}
+
+// statusOrder 具体状态码在前，范围其次，default 最后
+func statusOrder(code string) string {
+	switch {
+	case code == "default":
+		return "3"
+	case strings.HasSuffix(strings.ToUpper(code), "XX"):
+		return "2" + code
+	}
+	return "1" + code
+}
+
+// jsonMedia 选出 JSON 内容
+func jsonMedia(content map[string]*MediaType) (*MediaType, bool) {
+	types := make([]string, 0, len(content))
+	for ct := range content {
+		types = append(types, ct)
+	}
+	sort.Strings(types)
+	for _, ct := range types {
+		if ct == "application/json" || strings.HasSuffix(ct, "+json") {
+			return content[ct], true
+		}
+	}
+	return nil, false
+}
+
+// argType 参数的类型，结构体使用指针
+func (g *Generator) argType(typ string) string {
+	if g.structs[typ] {
+		return "*" + typ
+	}
+	return typ
+}
+
+// stringer 将 expr 转为字符串的表达式
+func (g *Generator) stringer(expr, typ string) string {
+	if typ == "string" {
+		return expr
+	}
+	return g.use("fmt") + ".Sprint(" + expr + ")"
+}
+
+func (g *Generator) genOperation(w *bytes.Buffer, o *operation) error {
+	// 参数结构体
+	paramsType := ""
+	paramTypes := map[*Parameter]string{}
+	if len(o.params) > 0 {
+		paramsType = g.reserve(o.name + "Params")
+		decl := &bytes.Buffer{}
+		g.decls = append(g.decls, decl)
+		fmt.Fprintf(decl, "// %s %s 的参数\n", paramsType, o.name)
+		fmt.Fprintf(decl, "type %s struct {\n", paramsType)
+		for _, p := range o.params {
+			typ, err := g.typeOf(p.Schema, paramsType+goName(p.Name))
+			if err != nil {
+				return fmt.Errorf("parameter %s: %w", p.Name, err)
+			}
+			paramTypes[p] = typ
+			if !p.Required && pointable(typ) {
+				typ = "*" + typ
+			}
+			desc := p.In + " " + p.Name
+			if p.Description != "" {
+				desc += ", " + strings.Replace(strings.TrimSpace(p.Description), "\n", " ", -1)
+			}
+			fmt.Fprintf(decl, "\t%s %s // %s\n", goName(p.Name), typ, desc)
+		}
+		decl.WriteString("}\n")
+	}
+
+	// 响应结构体
+	respType := g.reserve(o.name + "Response")
+	type decoded struct {
+		code, field, typ string
+		pointer          bool
+	}
+	var results []decoded
+	decl := &bytes.Buffer{}
+	g.decls = append(g.decls, decl)
+	fmt.Fprintf(decl, "// %s %s 的响应，按状态码解码\n", respType, o.name)
+	fmt.Fprintf(decl, "type %s struct {\n", respType)
+	fmt.Fprintf(decl, "\tStatusCode int\n\tHeader     %s.Header\n\tBody       []byte // 没有解码的响应内容\n", g.use("http"))
+	for _, code := range o.responses {
+		r, err := g.doc.response(o.op.Responses[code])
+		if err != nil {
+			return err
+		}
+		media, ok := jsonMedia(r.Content)
+		if !ok || media.Schema == nil {
+			continue
+		}
+		suffix := strings.ToUpper(code)
+		if code == "default" {
+			suffix = "Default"
+		}
+		typ, err := g.typeOf(media.Schema, o.name+"Result"+suffix)
+		if err != nil {
+			return fmt.Errorf("response %s: %w", code, err)
+		}
+		d := decoded{code: code, field: "JSON" + suffix, typ: typ, pointer: g.structs[typ]}
+		results = append(results, d)
+		fieldType := typ
+		if d.pointer {
+			fieldType = "*" + typ
+		}
+		desc := strings.Replace(strings.TrimSpace(r.Description), "\n", " ", -1)
+		fmt.Fprintf(decl, "\t%s %s // %s %s\n", d.field, fieldType, code, desc)
+	}
+	decl.WriteString("}\n")
+
+	// 方法签名
+	name := o.name
+	w.WriteString("\n")
+	if summary := strings.TrimSpace(o.op.Summary); summary != "" {
+		comment(w, "", name, summary)
+	} else {
+		fmt.Fprintf(w, "// %s %s %s\n", name, o.method, o.path)
+	}
+	if o.op.Description != "" {
+		w.WriteString("//\n")
+		comment(w, "", "", o.op.Description)
+	}
+	if o.op.Deprecated {
+		w.WriteString("//\n// Deprecated: 接口已废弃\n")
+	}
+
+	args := []string{"ctx context.Context"}
+	pathVars := map[string]string{}
+	argNames := map[string]bool{}
+	for k := range locals {
+		argNames[k] = true
+	}
+	for _, p := range o.pathArgs {
+		typ, err := g.typeOf(p.Schema, o.name+goName(p.Name))
+		if err != nil {
+			return fmt.Errorf("parameter %s: %w", p.Name, err)
+		}
+		v := varName(p.Name, argNames)
+		argNames[v] = true
+		pathVars[p.Name] = g.stringer(v, typ)
+		args = append(args, v+" "+typ)
+	}
+	if paramsType != "" {
+		args = append(args, "params *"+paramsType)
+	}
+
+	bodyKind := ""
+	var bodyContentType string
+	if o.body != nil && len(o.body.Content) > 0 {
+		if media, ok := jsonMedia(o.body.Content); ok {
+			typ, err := g.typeOf(media.Schema, o.name+"Body")
+			if err != nil {
+				return fmt.Errorf("request body: %w", err)
+			}
+			bodyKind = "json"
+			args = append(args, "body "+g.argType(typ))
+		} else if _, ok := o.body.Content["application/x-www-form-urlencoded"]; ok {
+			bodyKind = "form"
+			args = append(args, "body "+g.use("url")+".Values")
+		} else {
+			types := make([]string, 0, len(o.body.Content))
+			for ct := range o.body.Content {
+				types = append(types, ct)
+			}
+			sort.Strings(types)
+			bodyContentType = types[0]
+			bodyKind = "raw"
+			if strings.HasPrefix(bodyContentType, "multipart/") {
+				bodyKind = "multipart"
+				args = append(args, "contentType string")
+			}
+			args = append(args, "body "+g.use("io")+".Reader")
+		}
+	}
+	fmt.Fprintf(w, "func (c *%s) %s(%s) (*%s, error) {\n", g.client, name, strings.Join(args, ", "), respType)
+
+	// 路径
+	var pathExpr []string
+	rest := o.path
+	for {
+		i := strings.IndexByte(rest, '{')
+		j := strings.IndexByte(rest, '}')
+		if i < 0 || j < i {
+			break
+		}
+		expr, ok := pathVars[rest[i+1:j]]
+		if !ok {
+			return fmt.Errorf("%w: path parameter %s not declared", ErrSpec, rest[i+1:j])
+		}
+		if i > 0 {
+			pathExpr = append(pathExpr, strconv.Quote(rest[:i]))
+		}
+		pathExpr = append(pathExpr, g.use("url")+".PathEscape("+expr+")")
+		rest = rest[j+1:]
+	}
+	if rest != "" || len(pathExpr) == 0 {
+		pathExpr = append(pathExpr, strconv.Quote(rest))
+	}
+
+	fmt.Fprintf(w, "\tquery := %s.Values{}\n", g.use("url"))
+	fmt.Fprintf(w, "\treq := c.request(ctx, %s, %s)\n", methodConsts[o.method], strings.Join(pathExpr, "+"))
+
+	if paramsType != "" {
+		w.WriteString("\tif params != nil {\n")
+		for _, p := range o.params {
+			field := "params." + goName(p.Name)
+			typ := paramTypes[p]
+			indent := "\t\t"
+			if !p.Required && pointable(typ) {
+				fmt.Fprintf(w, "\t\tif %s != nil {\n", field)
+				field, indent = "*"+field, "\t\t\t"
+			}
+			value := g.stringer(field, typ)
+			if strings.HasPrefix(typ, "[]") {
+				fmt.Fprintf(w, "%sfor _, v := range %s {\n", indent, field)
+				indent += "\t"
+				value = g.stringer("v", typ[2:])
+			}
+			switch p.In {
+			case "query":
+				method := "Set"
+				if strings.HasPrefix(typ, "[]") {
+					method = "Add"
+				}
+				fmt.Fprintf(w, "%squery.%s(%q, %s)\n", indent, method, p.Name, value)
+			case "header":
+				fmt.Fprintf(w, "%sreq.HeaderWith(urlx.HeaderSet(%q, %s))\n", indent, p.Name, value)
+			case "cookie":
+				fmt.Fprintf(w, "%s{\n%s\tv := %s\n%s\treq.HeaderWith(func(h %s.Header) { h.Add(\"Cookie\", %q+v) })\n%s}\n", indent, indent, value, indent, g.use("http"), p.Name+"=", indent)
+			}
+			if strings.HasPrefix(typ, "[]") {
+				indent = indent[:len(indent)-1]
+				fmt.Fprintf(w, "%s}\n", indent)
+			}
+			if !p.Required && pointable(typ) {
+				w.WriteString("\t\t}\n")
+			}
+		}
+		w.WriteString("\t}\n")
+	}
+
+	switch bodyKind {
+	case "json":
+		w.WriteString("\treq.SendJSON(body)\n")
+	case "form":
+		w.WriteString("\treq.SendForm(body)\n")
+	case "raw":
+		fmt.Fprintf(w, "\treq.SendBody(func() (string, io.Reader, error) { return %q, body, nil })\n", bodyContentType)
+	case "multipart":
+		w.WriteString("\treq.SendBody(func() (string, io.Reader, error) { return contentType, body, nil })\n")
+	}
+
+	// 多个认证方式之间是或的关系，使用第一个设置了认证信息的
+	for i, requirement := range o.security {
+		conds := make([]string, len(requirement))
+		for j, scheme := range requirement {
+			conds[j] = "c.has" + goName(scheme) + "()"
+		}
+		indent := "\t\t"
+		switch {
+		case len(o.security) == 1:
+			fmt.Fprintf(w, "\tif %s {\n", strings.Join(conds, " && "))
+		case i == 0:
+			fmt.Fprintf(w, "\tswitch {\n\tcase %s:\n", strings.Join(conds, " && "))
+		default:
+			fmt.Fprintf(w, "\tcase %s:\n", strings.Join(conds, " && "))
+		}
+		for _, scheme := range requirement {
+			fmt.Fprintf(w, "%sc.auth%s(req, query)\n", indent, goName(scheme))
+		}
+		if i == len(o.security)-1 {
+			w.WriteString("\t}\n")
+		}
+	}
+	w.WriteString("\treq.Query(query.Encode())\n\n")
+
+	// 响应
+	fmt.Fprintf(w, "\tout := &%s{}\n", respType)
+	fmt.Fprintf(w, "\terr := req.Process(func(resp *http.Response, body %s.ReadCloser) (err error) {\n", g.use("io"))
+	w.WriteString("\t\tout.StatusCode, out.Header = resp.StatusCode, resp.Header\n")
+	hasDefault := false
+	if len(results) > 0 {
+		w.WriteString("\t\tswitch {\n")
+		for _, d := range results {
+			switch {
+			case d.code == "default":
+				hasDefault = true
+				w.WriteString("\t\tdefault:\n")
+			case strings.HasSuffix(strings.ToUpper(d.code), "XX"):
+				base := int(d.code[0]-'0') * 100
+				fmt.Fprintf(w, "\t\tcase resp.StatusCode >= %d && resp.StatusCode < %d:\n", base, base+100)
+			default:
+				fmt.Fprintf(w, "\t\tcase resp.StatusCode == %s:\n", d.code)
+			}
+			if d.pointer {
+				fmt.Fprintf(w, "\t\t\tout.%s = new(%s)\n", d.field, d.typ)
+				fmt.Fprintf(w, "\t\t\treturn urlx.JSON(out.%s)(resp, body)\n", d.field)
+			} else {
+				fmt.Fprintf(w, "\t\t\treturn urlx.JSON(&out.%s)(resp, body)\n", d.field)
+			}
+		}
+		w.WriteString("\t\t}\n")
+	}
+	if !hasDefault {
+		w.WriteString("\t\tout.Body, err = io.ReadAll(body)\n\t\treturn\n")
+	}
+	w.WriteString("\t})\n")
+	w.WriteString("\tif err != nil {\n\t\treturn nil, err\n\t}\n\treturn out, nil\n}\n")
+	return nil
+}
@@ -0,0 +1,29 @@
+module github.com/cnk3x/go/urlx/cmd/urlx-gen
+
+go 1.18
+
+// 仓库内使用本地目录构建。urlx 和 flagx 发布版本之前不能 go install ...@latest，发布后 require 改为发布的版本
+replace (
+	github.com/cnk3x/go/flagx => ../../../flagx
+	github.com/cnk3x/go/urlx => ../../
+)
+
+require (
+	github.com/cnk3x/go/flagx v0.0.0-00010101000000-000000000000
+	github.com/cnk3x/go/urlx v0.0.0-00010101000000-000000000000
+	github.com/goccy/go-json v0.8.1
+	github.com/goccy/go-yaml v1.9.4
+)
+
+require (
+	github.com/andybalholm/brotli v1.0.4 // indirect
+	github.com/fatih/color v1.10.0 // indirect
+	github.com/google/go-querystring v1.1.0 // indirect
+	github.com/klauspost/compress v1.13.6 // indirect
+	github.com/mattn/go-colorable v0.1.8 // indirect
+	github.com/mattn/go-isatty v0.0.13 // indirect
+	golang.org/x/net v0.0.0-20211216030914-fe4d6282115f // indirect
+	golang.org/x/sys v0.0.0-20211205182925-97ca703d548d // indirect
+	golang.org/x/text v0.3.7 // indirect
+	golang.org/x/xerrors v0.0.0-20200804184101-5ec99f83aff1 // indirect
+)
@@ -0,0 +1,4 @@
+// Package petstore 由 testdata/petstore.yaml 生成的示例客户端，用于测试生成的代码
+package petstore
+
+//go:generate go run ../.. gen -spec ../../testdata/petstore.yaml -package petstore -o petstore.go
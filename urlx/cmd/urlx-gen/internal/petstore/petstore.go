@@ -0,0 +1,345 @@
+// Code generated by urlx-gen. DO NOT EDIT.
+
+// Package petstore Petstore 的客户端，版本 1.0.0
+package petstore
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cnk3x/go/urlx"
+)
+
+type Error struct {
+	Code    int32  `json:"code"`
+	Message string `json:"message"`
+}
+
+type NewPet struct {
+	Attributes map[string]string `json:"attributes,omitempty"`
+	Name       string            `json:"name"`
+	Status     *Status           `json:"status,omitempty"`
+	Tag        *string           `json:"tag,omitempty"`
+}
+
+// Pet A pet in the store.
+type Pet struct {
+	NewPet
+	ID    int64     `json:"id"`
+	Owner *PetOwner `json:"owner,omitempty"`
+}
+
+type PetOwner struct {
+	Name *string `json:"name,omitempty"`
+}
+
+type Pets []Pet
+
+type Status string
+
+const (
+	StatusAvailable Status = "available"
+	StatusPending   Status = "pending"
+	StatusSold      Status = "sold"
+)
+
+// LoginResponse Login 的响应，按状态码解码
+type LoginResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte          // 没有解码的响应内容
+	JSON200    *LoginResult200 // 200 Session
+}
+
+type LoginResult200 struct {
+	Expires *time.Time `json:"expires,omitempty"`
+	Token   string     `json:"token"`
+}
+
+// ListPetsParams ListPets 的参数
+type ListPetsParams struct {
+	Limit      *int32   // query limit, How many items to return at one time
+	Tags       []string // query tags
+	XRequestID string   // header X-Request-ID
+}
+
+// ListPetsResponse ListPets 的响应，按状态码解码
+type ListPetsResponse struct {
+	StatusCode  int
+	Header      http.Header
+	Body        []byte // 没有解码的响应内容
+	JSON200     Pets   // 200 A paged array of pets
+	JSONDefault *Error // default Unexpected error
+}
+
+// CreatePetResponse CreatePet 的响应，按状态码解码
+type CreatePetResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte // 没有解码的响应内容
+	JSON201    *Pet   // 201 Created
+	JSON4XX    *Error // 4XX Unexpected error
+}
+
+// ShowPetByIDResponse ShowPetByID 的响应，按状态码解码
+type ShowPetByIDResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte                // 没有解码的响应内容
+	JSON200    *Pet                  // 200 Expected response to a valid request
+	JSON404    *ShowPetByIDResult404 // 404 Not found
+}
+
+type ShowPetByIDResult404 struct {
+	Message *string `json:"message,omitempty"`
+}
+
+// DeletePetResponse DeletePet 的响应，按状态码解码
+type DeletePetResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte // 没有解码的响应内容
+}
+
+// PutPetsPetIDPhotoParams PutPetsPetIDPhoto 的参数
+type PutPetsPetIDPhotoParams struct {
+	Token string // query token
+}
+
+// PutPetsPetIDPhotoResponse PutPetsPetIDPhoto 的响应，按状态码解码
+type PutPetsPetIDPhotoResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte // 没有解码的响应内容
+}
+
+// DefaultServer 文档中的第一个服务地址
+const DefaultServer = "http://petstore.example.com/v1"
+
+// Client 接口客户端，认证信息为空时不发送
+type Client struct {
+	BaseURL string        // 服务地址
+	Options []urlx.Option // 每个请求的选项
+
+	APIKey string // apiKey API Key，header X-API-Key
+
+	BasicAuthUsername string // basicAuth Basic 认证
+	BasicAuthPassword string // basicAuth Basic 认证
+
+	BearerAuth string // bearerAuth Bearer Token
+
+	QueryKey string // queryKey API Key，query api_key
+}
+
+// NewClient 创建客户端
+func NewClient(baseURL string, options ...urlx.Option) *Client {
+	return &Client{BaseURL: baseURL, Options: options}
+}
+
+func (c *Client) request(ctx context.Context, method, path string) *urlx.Request {
+	return urlx.Default(ctx).With(c.Options...).Method(method).Url(strings.TrimRight(c.BaseURL, "/") + path).HeaderWith(urlx.Accept("application/json"))
+}
+
+func (c *Client) hasAPIKey() bool {
+	return c.APIKey != ""
+}
+
+func (c *Client) authAPIKey(req *urlx.Request, query url.Values) {
+	req.HeaderWith(urlx.HeaderSet("X-API-Key", c.APIKey))
+}
+
+func (c *Client) hasBasicAuth() bool {
+	return c.BasicAuthUsername != "" || c.BasicAuthPassword != ""
+}
+
+func (c *Client) authBasicAuth(req *urlx.Request, query url.Values) {
+	req.HeaderWith(urlx.HeaderSet("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(c.BasicAuthUsername+":"+c.BasicAuthPassword))))
+}
+
+func (c *Client) hasBearerAuth() bool {
+	return c.BearerAuth != ""
+}
+
+func (c *Client) authBearerAuth(req *urlx.Request, query url.Values) {
+	req.HeaderWith(urlx.HeaderSet("Authorization", "Bearer "+c.BearerAuth))
+}
+
+func (c *Client) hasQueryKey() bool {
+	return c.QueryKey != ""
+}
+
+func (c *Client) authQueryKey(req *urlx.Request, query url.Values) {
+	query.Set("api_key", c.QueryKey)
+}
+
+// Login POST /login
+func (c *Client) Login(ctx context.Context, body url.Values) (*LoginResponse, error) {
+	query := url.Values{}
+	req := c.request(ctx, urlx.MethodPost, "/login")
+	req.SendForm(body)
+	req.Query(query.Encode())
+
+	out := &LoginResponse{}
+	err := req.Process(func(resp *http.Response, body io.ReadCloser) (err error) {
+		out.StatusCode, out.Header = resp.StatusCode, resp.Header
+		switch {
+		case resp.StatusCode == 200:
+			out.JSON200 = new(LoginResult200)
+			return urlx.JSON(out.JSON200)(resp, body)
+		}
+		out.Body, err = io.ReadAll(body)
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ListPets List all pets
+func (c *Client) ListPets(ctx context.Context, params *ListPetsParams) (*ListPetsResponse, error) {
+	query := url.Values{}
+	req := c.request(ctx, urlx.MethodGet, "/pets")
+	if params != nil {
+		if params.Limit != nil {
+			query.Set("limit", fmt.Sprint(*params.Limit))
+		}
+		for _, v := range params.Tags {
+			query.Add("tags", v)
+		}
+		req.HeaderWith(urlx.HeaderSet("X-Request-ID", params.XRequestID))
+	}
+	if c.hasBearerAuth() {
+		c.authBearerAuth(req, query)
+	}
+	req.Query(query.Encode())
+
+	out := &ListPetsResponse{}
+	err := req.Process(func(resp *http.Response, body io.ReadCloser) (err error) {
+		out.StatusCode, out.Header = resp.StatusCode, resp.Header
+		switch {
+		case resp.StatusCode == 200:
+			return urlx.JSON(&out.JSON200)(resp, body)
+		default:
+			out.JSONDefault = new(Error)
+			return urlx.JSON(out.JSONDefault)(resp, body)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CreatePet Create a pet
+func (c *Client) CreatePet(ctx context.Context, body *NewPet) (*CreatePetResponse, error) {
+	query := url.Values{}
+	req := c.request(ctx, urlx.MethodPost, "/pets")
+	req.SendJSON(body)
+	if c.hasBearerAuth() {
+		c.authBearerAuth(req, query)
+	}
+	req.Query(query.Encode())
+
+	out := &CreatePetResponse{}
+	err := req.Process(func(resp *http.Response, body io.ReadCloser) (err error) {
+		out.StatusCode, out.Header = resp.StatusCode, resp.Header
+		switch {
+		case resp.StatusCode == 201:
+			out.JSON201 = new(Pet)
+			return urlx.JSON(out.JSON201)(resp, body)
+		case resp.StatusCode >= 400 && resp.StatusCode < 500:
+			out.JSON4XX = new(Error)
+			return urlx.JSON(out.JSON4XX)(resp, body)
+		}
+		out.Body, err = io.ReadAll(body)
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ShowPetByID Info for a specific pet
+func (c *Client) ShowPetByID(ctx context.Context, petID int64) (*ShowPetByIDResponse, error) {
+	query := url.Values{}
+	req := c.request(ctx, urlx.MethodGet, "/pets/"+url.PathEscape(fmt.Sprint(petID)))
+	switch {
+	case c.hasAPIKey():
+		c.authAPIKey(req, query)
+	case c.hasBasicAuth():
+		c.authBasicAuth(req, query)
+	}
+	req.Query(query.Encode())
+
+	out := &ShowPetByIDResponse{}
+	err := req.Process(func(resp *http.Response, body io.ReadCloser) (err error) {
+		out.StatusCode, out.Header = resp.StatusCode, resp.Header
+		switch {
+		case resp.StatusCode == 200:
+			out.JSON200 = new(Pet)
+			return urlx.JSON(out.JSON200)(resp, body)
+		case resp.StatusCode == 404:
+			out.JSON404 = new(ShowPetByIDResult404)
+			return urlx.JSON(out.JSON404)(resp, body)
+		}
+		out.Body, err = io.ReadAll(body)
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DeletePet DELETE /pets/{petId}
+//
+// Deprecated: 接口已废弃
+func (c *Client) DeletePet(ctx context.Context, petID int64) (*DeletePetResponse, error) {
+	query := url.Values{}
+	req := c.request(ctx, urlx.MethodDelete, "/pets/"+url.PathEscape(fmt.Sprint(petID)))
+	req.Query(query.Encode())
+
+	out := &DeletePetResponse{}
+	err := req.Process(func(resp *http.Response, body io.ReadCloser) (err error) {
+		out.StatusCode, out.Header = resp.StatusCode, resp.Header
+		out.Body, err = io.ReadAll(body)
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PutPetsPetIDPhoto Upload a photo
+func (c *Client) PutPetsPetIDPhoto(ctx context.Context, petID int64, params *PutPetsPetIDPhotoParams, body io.Reader) (*PutPetsPetIDPhotoResponse, error) {
+	query := url.Values{}
+	req := c.request(ctx, urlx.MethodPut, "/pets/"+url.PathEscape(fmt.Sprint(petID))+"/photo")
+	if params != nil {
+		query.Set("token", params.Token)
+	}
+	req.SendBody(func() (string, io.Reader, error) { return "image/png", body, nil })
+	if c.hasQueryKey() {
+		c.authQueryKey(req, query)
+	}
+	req.Query(query.Encode())
+
+	out := &PutPetsPetIDPhotoResponse{}
+	err := req.Process(func(resp *http.Response, body io.ReadCloser) (err error) {
+		out.StatusCode, out.Header = resp.StatusCode, resp.Header
+		out.Body, err = io.ReadAll(body)
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,96 @@
+package petstore
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestClient(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/pets":
+			if r.Header.Get("Authorization") != "Bearer secret" {
+				rw.WriteHeader(http.StatusUnauthorized)
+				_ = json.NewEncoder(rw).Encode(Error{Code: 401, Message: "unauthorized"})
+				return
+			}
+			name := r.Header.Get("X-Request-ID") + ":" + r.URL.Query().Get("limit") + ":" + strings.Join(r.URL.Query()["tags"], ",")
+			_ = json.NewEncoder(rw).Encode(Pets{{NewPet: NewPet{Name: name}, ID: 1}})
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/pets":
+			var in NewPet
+			_ = json.NewDecoder(r.Body).Decode(&in)
+			rw.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(rw).Encode(Pet{NewPet: in, ID: 2})
+		case r.URL.Path == "/v1/pets/7":
+			if key := r.Header.Get("X-API-Key"); key != "" && r.Header.Get("Authorization") == "" {
+				_ = json.NewEncoder(rw).Encode(Pet{NewPet: NewPet{Name: key}, ID: 7})
+				return
+			}
+			if user, pass, ok := r.BasicAuth(); ok && user == "u" && pass == "p" {
+				_ = json.NewEncoder(rw).Encode(Pet{NewPet: NewPet{Name: "seven"}, ID: 7})
+				return
+			}
+			rw.WriteHeader(http.StatusNotFound)
+			_, _ = rw.Write([]byte(`{"message":"no pet"}`))
+		case r.URL.Path == "/v1/pets/7/photo":
+			data, _ := io.ReadAll(r.Body)
+			_, _ = rw.Write([]byte(r.URL.RawQuery + "|" + r.Header.Get("Content-Type") + "|" + string(data)))
+		case r.URL.Path == "/v1/login":
+			_ = json.NewEncoder(rw).Encode(map[string]string{"token": r.FormValue("username")})
+		}
+	}))
+	defer s.Close()
+
+	ctx := context.Background()
+	c := NewClient(s.URL + "/v1")
+
+	list, err := c.ListPets(ctx, nil)
+	if err != nil || list.StatusCode != http.StatusUnauthorized || list.JSONDefault == nil || list.JSONDefault.Message != "unauthorized" {
+		t.Fatalf("unauthorized list: %+v, %v", list, err)
+	}
+
+	c.BearerAuth = "secret"
+	limit := int32(5)
+	list, err = c.ListPets(ctx, &ListPetsParams{Limit: &limit, Tags: []string{"a", "b"}, XRequestID: "rid"})
+	if err != nil || len(list.JSON200) != 1 || list.JSON200[0].Name != "rid:5:a,b" {
+		t.Fatalf("list: %+v, %v", list, err)
+	}
+
+	status := StatusPending
+	created, err := c.CreatePet(ctx, &NewPet{Name: "kitty", Status: &status})
+	if err != nil || created.JSON201 == nil || created.JSON201.ID != 2 || *created.JSON201.Status != StatusPending {
+		t.Fatalf("create: %+v, %v", created, err)
+	}
+
+	pet, err := c.ShowPetByID(ctx, 7)
+	if err != nil || pet.JSON404 == nil || *pet.JSON404.Message != "no pet" {
+		t.Fatalf("show without auth: %+v, %v", pet, err)
+	}
+	c.BasicAuthUsername, c.BasicAuthPassword = "u", "p"
+	if pet, err = c.ShowPetByID(ctx, 7); err != nil || pet.JSON200 == nil || pet.JSON200.Name != "seven" {
+		t.Fatalf("show: %+v, %v", pet, err)
+	}
+	// 可选的认证方式只使用第一个设置了的
+	c.APIKey = "key"
+	if pet, err = c.ShowPetByID(ctx, 7); err != nil || pet.JSON200 == nil || pet.JSON200.Name != "key" {
+		t.Fatalf("show with api key: %+v, %v", pet, err)
+	}
+
+	c.QueryKey = "k"
+	photo, err := c.PutPetsPetIDPhoto(ctx, 7, &PutPetsPetIDPhotoParams{Token: "t"}, strings.NewReader("png"))
+	if err != nil || string(photo.Body) != "api_key=k&token=t|image/png|png" {
+		t.Fatalf("photo: %+v, %v", photo, err)
+	}
+
+	login, err := c.Login(ctx, url.Values{"username": {"bob"}})
+	if err != nil || login.JSON200 == nil || login.JSON200.Token != "bob" {
+		t.Fatalf("login: %+v, %v", login, err)
+	}
+}
@@ -0,0 +1,58 @@
+// urlx-gen 由 OpenAPI 3 文档生成基于 urlx 的客户端代码
+//
+//	urlx-gen gen -spec petstore.yaml -package petstore -o petstore/client.go
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/cnk3x/go/flagx"
+)
+
+func main() {
+	flagx.New().
+		AddCommand("gen", &genCommand{Package: "client", Client: "Client"}).
+		Run(context.Background())
+}
+
+// genCommand 生成客户端代码
+type genCommand struct {
+	Spec    string `flag:"spec" short:"s" usage:"OpenAPI 3 文档，YAML 或 JSON 格式"`
+	Output  string `flag:"out" short:"o" usage:"输出文件，为空时输出到标准输出"`
+	Package string `flag:"package" short:"p" usage:"生成代码的包名"`
+	Client  string `flag:"client" usage:"客户端类型名称"`
+}
+
+func (cmd *genCommand) Usage() string {
+	return "由 OpenAPI 3 文档生成客户端代码"
+}
+
+func (cmd *genCommand) Run(ctx context.Context, args []string) error {
+	if cmd.Spec == "" && len(args) > 0 {
+		cmd.Spec = args[0]
+	}
+	if cmd.Spec == "" {
+		return errors.New("缺少参数 -spec")
+	}
+
+	doc, err := Load(cmd.Spec)
+	if err != nil {
+		return err
+	}
+	src, err := Generate(doc, cmd.Package, cmd.Client)
+	if err != nil {
+		return err
+	}
+
+	if cmd.Output == "" {
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(cmd.Output), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(cmd.Output, src, 0644)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "更新 internal/petstore/petstore.go")
+
+func TestGeneratePetstore(t *testing.T) {
+	doc, err := Load("testdata/petstore.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := Generate(doc, "petstore", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const golden = "internal/petstore/petstore.go"
+	if *update {
+		if err = os.WriteFile(golden, src, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(src, want) {
+		t.Fatalf("generated code differs from %s, run go generate ./internal/petstore", golden)
+	}
+}
+
+func TestGenerateJSON(t *testing.T) {
+	doc, err := Load("testdata/minimal.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := Generate(doc, "minimal", "API")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"type API struct",
+		"func (c *API) GetItemsID(ctx context.Context, id string, params *GetItemsIDParams) (*GetItemsIDResponse, error)",
+		`"/items/"+url.PathEscape(id)`,
+		"JSON200    map[string]interface{}",
+		`h.Add("Cookie", "session="+v)`,
+	} {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("missing %q in:\n%s", want, src)
+		}
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	for name, spec := range map[string]string{
+		"version": `{"openapi": "2.0", "paths": {}}`,
+		"ref":     `{"openapi": "3.0.0", "paths": {"/a": {"get": {"responses": {"200": {"description": "x", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Missing"}}}}}}}}}`,
+		"path":    `{"openapi": "3.0.0", "paths": {"/a/{id}": {"get": {"responses": {}}}}}`,
+	} {
+		doc, err := Parse([]byte(spec))
+		if err == nil {
+			_, err = Generate(doc, "x", "")
+		}
+		if !errors.Is(err, ErrSpec) {
+			t.Errorf("%s: want ErrSpec, got %v", name, err)
+		}
+	}
+}
+
+func TestGoName(t *testing.T) {
+	for in, want := range map[string]string{
+		"petId":        "PetID",
+		"x-request-id": "XRequestID",
+		"api_key":      "APIKey",
+		"listPets":     "ListPets",
+		"200":          "N200",
+		"HTTPServer":   "HTTPServer",
+	} {
+		if got := goName(in); got != want {
+			t.Errorf("goName(%q) = %q, want %q", in, got, want)
+		}
+	}
+	reserved := map[string]bool{"body": true}
+	for in, want := range map[string]string{"PetID": "petID", "id": "id", "URLPath": "urlPath", "type": "typeParam", "body": "bodyParam"} {
+		if got := varName(in, reserved); got != want {
+			t.Errorf("varName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
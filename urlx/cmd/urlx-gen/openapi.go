@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/goccy/go-json"
+	"github.com/goccy/go-yaml"
+)
+
+var ErrSpec = errors.New("openapi: invalid spec")
+
+// Document OpenAPI 3 文档，只包含生成代码用到的部分
+type Document struct {
+	OpenAPI    string                `json:"openapi"`
+	Info       Info                  `json:"info"`
+	Servers    []Server              `json:"servers"`
+	Paths      map[string]*PathItem  `json:"paths"`
+	Components Components            `json:"components"`
+	Security   []SecurityRequirement `json:"security"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Version     string `json:"version"`
+}
+
+type Server struct {
+	URL string `json:"url"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	Parameters      map[string]*Parameter      `json:"parameters"`
+	RequestBodies   map[string]*RequestBody    `json:"requestBodies"`
+	Responses       map[string]*Response       `json:"responses"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes"`
+}
+
+// SecurityRequirement 方案名称到作用域
+type SecurityRequirement map[string][]string
+
+type PathItem struct {
+	Parameters []*Parameter `json:"parameters"`
+	Get        *Operation   `json:"get"`
+	Put        *Operation   `json:"put"`
+	Post       *Operation   `json:"post"`
+	Delete     *Operation   `json:"delete"`
+	Options    *Operation   `json:"options"`
+	Head       *Operation   `json:"head"`
+	Patch      *Operation   `json:"patch"`
+	Trace      *Operation   `json:"trace"`
+}
+
+// Operations 按方法排列的操作
+func (p *PathItem) Operations() (methods []string, ops []*Operation) {
+	for _, x := range []struct {
+		method string
+		op     *Operation
+	}{
+		{"GET", p.Get}, {"PUT", p.Put}, {"POST", p.Post}, {"DELETE", p.Delete},
+		{"OPTIONS", p.Options}, {"HEAD", p.Head}, {"PATCH", p.Patch}, {"TRACE", p.Trace},
+	} {
+		if x.op != nil {
+			methods, ops = append(methods, x.method), append(ops, x.op)
+		}
+	}
+	return
+}
+
+type Operation struct {
+	OperationID string                 `json:"operationId"`
+	Summary     string                 `json:"summary"`
+	Description string                 `json:"description"`
+	Deprecated  bool                   `json:"deprecated"`
+	Parameters  []*Parameter           `json:"parameters"`
+	RequestBody *RequestBody           `json:"requestBody"`
+	Responses   map[string]*Response   `json:"responses"`
+	Security    *[]SecurityRequirement `json:"security"`
+}
+
+type Parameter struct {
+	Ref         string  `json:"$ref"`
+	Name        string  `json:"name"`
+	In          string  `json:"in"` // path, query, header, cookie
+	Description string  `json:"description"`
+	Required    bool    `json:"required"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Ref         string                `json:"$ref"`
+	Description string                `json:"description"`
+	Required    bool                  `json:"required"`
+	Content     map[string]*MediaType `json:"content"`
+}
+
+type Response struct {
+	Ref         string                `json:"$ref"`
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`   // apiKey, http, oauth2, openIdConnect
+	Scheme       string `json:"scheme"` // basic, bearer
+	BearerFormat string `json:"bearerFormat"`
+	Name         string `json:"name"`
+	In           string `json:"in"` // header, query, cookie
+	Description  string `json:"description"`
+}
+
+type Schema struct {
+	Ref                  string             `json:"$ref"`
+	Type                 string             `json:"type"`
+	Format               string             `json:"format"`
+	Description          string             `json:"description"`
+	Nullable             bool               `json:"nullable"`
+	Enum                 []any              `json:"enum"`
+	Required             []string           `json:"required"`
+	Properties           map[string]*Schema `json:"properties"`
+	Items                *Schema            `json:"items"`
+	AllOf                []*Schema          `json:"allOf"`
+	OneOf                []*Schema          `json:"oneOf"`
+	AnyOf                []*Schema          `json:"anyOf"`
+	AdditionalProperties *Additional        `json:"additionalProperties"`
+}
+
+// Additional additionalProperties 可以是布尔值或结构
+type Additional struct {
+	Allowed bool
+	Schema  *Schema
+}
+
+func (a *Additional) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("true")) || bytes.Equal(data, []byte("false")) {
+		a.Allowed = data[0] == 't'
+		return nil
+	}
+	a.Allowed, a.Schema = true, &Schema{}
+	return json.Unmarshal(data, a.Schema)
+}
+
+// Load 读取 YAML 或 JSON 格式的文档
+func Load(fn string) (*Document, error) {
+	data, err := os.ReadFile(fn)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Parse 解析 YAML 或 JSON 格式的文档
+func Parse(data []byte) (*Document, error) {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) == 0 || trimmed[0] != '{' {
+		var err error
+		if data, err = yaml.YAMLToJSON(data); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrSpec, err)
+		}
+	}
+
+	var doc Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSpec, err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		return nil, fmt.Errorf("%w: unsupported version %q", ErrSpec, doc.OpenAPI)
+	}
+	return &doc, nil
+}
+
+// refName 组件引用的名称，只支持本文档内的引用
+func refName(ref, kind string) (string, error) {
+	prefix := "#/components/" + kind + "/"
+	if !strings.HasPrefix(ref, prefix) {
+		return "", fmt.Errorf("%w: unsupported $ref %q", ErrSpec, ref)
+	}
+	return ref[len(prefix):], nil
+}
+
+func (doc *Document) parameter(p *Parameter) (*Parameter, error) {
+	if p.Ref == "" {
+		return p, nil
+	}
+	name, err := refName(p.Ref, "parameters")
+	if err != nil {
+		return nil, err
+	}
+	if x, ok := doc.Components.Parameters[name]; ok {
+		return doc.parameter(x)
+	}
+	return nil, fmt.Errorf("%w: %s not found", ErrSpec, p.Ref)
+}
+
+func (doc *Document) requestBody(b *RequestBody) (*RequestBody, error) {
+	if b == nil || b.Ref == "" {
+		return b, nil
+	}
+	name, err := refName(b.Ref, "requestBodies")
+	if err != nil {
+		return nil, err
+	}
+	if x, ok := doc.Components.RequestBodies[name]; ok {
+		return doc.requestBody(x)
+	}
+	return nil, fmt.Errorf("%w: %s not found", ErrSpec, b.Ref)
+}
+
+func (doc *Document) response(r *Response) (*Response, error) {
+	if r.Ref == "" {
+		return r, nil
+	}
+	name, err := refName(r.Ref, "responses")
+	if err != nil {
+		return nil, err
+	}
+	if x, ok := doc.Components.Responses[name]; ok {
+		return doc.response(x)
+	}
+	return nil, fmt.Errorf("%w: %s not found", ErrSpec, r.Ref)
+}
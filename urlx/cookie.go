@@ -0,0 +1,387 @@
+package urlx
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goccy/go-json"
+	"golang.org/x/net/publicsuffix"
+)
+
+var ErrCookieFormat = errors.New("cookie: invalid cookies file")
+
+// CookieJar 可持久化的 Cookie 容器，按公共后缀列表校验域名
+//
+// 实际的匹配由标准库 cookiejar 完成，这里额外保存一份完整记录用于列举和持久化
+type CookieJar struct {
+	mu      sync.Mutex
+	psl     cookiejar.PublicSuffixList
+	jar     *cookiejar.Jar
+	entries map[string]*CookieEntry // domain;path;name
+}
+
+// CookieEntry 保存的 Cookie 记录
+type CookieEntry struct {
+	Name     string    `json:"name"`
+	Value    string    `json:"value"`
+	Domain   string    `json:"domain"`
+	Path     string    `json:"path"`
+	HostOnly bool      `json:"host_only,omitempty"`
+	Secure   bool      `json:"secure,omitempty"`
+	HttpOnly bool      `json:"http_only,omitempty"`
+	Expires  time.Time `json:"expires,omitempty"` // 零值为会话 Cookie，同样会被保存
+}
+
+// Cookie 转换为 http.Cookie
+func (e *CookieEntry) Cookie() *http.Cookie {
+	return &http.Cookie{Name: e.Name, Value: e.Value, Domain: e.Domain, Path: e.Path, Secure: e.Secure, HttpOnly: e.HttpOnly, Expires: e.Expires}
+}
+
+func (e *CookieEntry) id() string {
+	return e.Domain + ";" + e.Path + ";" + e.Name
+}
+
+func (e *CookieEntry) expired(now time.Time) bool {
+	return !e.Expires.IsZero() && !e.Expires.After(now)
+}
+
+// NewCookieJar 创建 Cookie 容器，psl 为空时使用 publicsuffix.List
+func NewCookieJar(psl cookiejar.PublicSuffixList) *CookieJar {
+	if psl == nil {
+		psl = publicsuffix.List
+	}
+	j := &CookieJar{psl: psl, entries: map[string]*CookieEntry{}}
+	j.jar, _ = cookiejar.New(&cookiejar.Options{PublicSuffixList: psl})
+	return j
+}
+
+// SetCookies 实现 http.CookieJar
+func (j *CookieJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.jar.SetCookies(u, cookies)
+
+	now := time.Now()
+	for _, cookie := range cookies {
+		e, ok := j.newEntry(u, cookie, now)
+		if !ok {
+			continue
+		}
+		if e.expired(now) {
+			delete(j.entries, e.id())
+		} else {
+			j.entries[e.id()] = e
+		}
+	}
+}
+
+// Cookies 实现 http.CookieJar
+func (j *CookieJar) Cookies(u *url.URL) []*http.Cookie {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.jar.Cookies(u)
+}
+
+// Domains 所有保存了 Cookie 的域名
+func (j *CookieJar) Domains() (domains []string) {
+	seen := map[string]bool{}
+	for _, e := range j.Entries() {
+		if !seen[e.Domain] {
+			seen[e.Domain] = true
+			domains = append(domains, e.Domain)
+		}
+	}
+	return
+}
+
+// Entries 所有未过期的 Cookie 记录
+func (j *CookieJar) Entries() (entries []*CookieEntry) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	now := time.Now()
+	for _, e := range j.entries {
+		if !e.expired(now) {
+			c := *e
+			entries = append(entries, &c)
+		}
+	}
+	sort.Slice(entries, func(a, b int) bool { return entries[a].id() < entries[b].id() })
+	return
+}
+
+// List 列出域名及其子域名下的 Cookie
+func (j *CookieJar) List(domain string) (cookies []*http.Cookie) {
+	domain = canonicalHost(domain)
+	for _, e := range j.Entries() {
+		if domainMatch(e.Domain, domain) {
+			cookies = append(cookies, e.Cookie())
+		}
+	}
+	return
+}
+
+// Set 在域名下设置 Cookie，未指定 Path 时为 "/"
+func (j *CookieJar) Set(domain string, cookies ...*http.Cookie) {
+	u := &url.URL{Scheme: "https", Host: canonicalHost(domain), Path: "/"}
+	for _, cookie := range cookies {
+		if cookie.Path == "" {
+			cookie.Path = "/"
+		}
+	}
+	j.SetCookies(u, cookies)
+}
+
+// Clear 清除域名及其子域名下的 Cookie
+func (j *CookieJar) Clear(domain string) {
+	domain = canonicalHost(domain)
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for id, e := range j.entries {
+		if domainMatch(e.Domain, domain) {
+			delete(j.entries, id)
+		}
+	}
+	j.rebuild()
+}
+
+// ClearAll 清除所有 Cookie
+func (j *CookieJar) ClearAll() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.entries = map[string]*CookieEntry{}
+	j.rebuild()
+}
+
+// Save 保存到文件，扩展名为 .json 时保存为 JSON，否则为 Netscape cookies.txt 格式
+func (j *CookieJar) Save(fn string) error {
+	if err := os.MkdirAll(filepath.Dir(fn), 0755); err != nil {
+		return err
+	}
+	tempFn := fn + ".urlx_jar_temp"
+	err := func() error {
+		f, err := os.OpenFile(tempFn, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if isJSONFile(fn) {
+			return j.WriteJSON(f)
+		}
+		return j.WriteNetscape(f)
+	}()
+	if err != nil {
+		_ = os.Remove(tempFn)
+		return err
+	}
+	return os.Rename(tempFn, fn)
+}
+
+// Load 从文件加载，格式同 Save，文件不存在时不报错
+func (j *CookieJar) Load(fn string) error {
+	f, err := os.Open(fn)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+	if isJSONFile(fn) {
+		return j.ReadJSON(f)
+	}
+	return j.ReadNetscape(f)
+}
+
+// WriteJSON 以 JSON 格式写出
+func (j *CookieJar) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(j.Entries())
+}
+
+// ReadJSON 读取 JSON 格式
+func (j *CookieJar) ReadJSON(r io.Reader) error {
+	var entries []*CookieEntry
+	if err := json.NewDecoder(r).Decode(&entries); err != nil {
+		return fmt.Errorf("%w: %v", ErrCookieFormat, err)
+	}
+	j.add(entries)
+	return nil
+}
+
+// WriteNetscape 以 Netscape cookies.txt 格式写出
+func (j *CookieJar) WriteNetscape(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "# Netscape HTTP Cookie File")
+	for _, e := range j.Entries() {
+		domain := e.Domain
+		if !e.HostOnly {
+			domain = "." + domain
+		}
+		if e.HttpOnly {
+			domain = "#HttpOnly_" + domain
+		}
+		var expires int64
+		if !e.Expires.IsZero() {
+			expires = e.Expires.Unix()
+		}
+		fmt.Fprintf(bw, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n", domain, netscapeBool(!e.HostOnly), e.Path, netscapeBool(e.Secure), expires, e.Name, e.Value)
+	}
+	return bw.Flush()
+}
+
+// ReadNetscape 读取 Netscape cookies.txt 格式
+func (j *CookieJar) ReadNetscape(r io.Reader) error {
+	var entries []*CookieEntry
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		var httpOnly bool
+		if strings.HasPrefix(line, "#HttpOnly_") {
+			line, httpOnly = strings.TrimPrefix(line, "#HttpOnly_"), true
+		}
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) != 7 {
+			return fmt.Errorf("%w: line %d", ErrCookieFormat, n)
+		}
+		expires, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			return fmt.Errorf("%w: line %d: %v", ErrCookieFormat, n, err)
+		}
+
+		e := &CookieEntry{
+			Domain:   canonicalHost(fields[0]),
+			HostOnly: !strings.EqualFold(fields[1], "TRUE"),
+			Path:     fields[2],
+			Secure:   strings.EqualFold(fields[3], "TRUE"),
+			Name:     fields[5],
+			Value:    fields[6],
+			HttpOnly: httpOnly,
+		}
+		if expires > 0 {
+			e.Expires = time.Unix(expires, 0)
+		}
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	j.add(entries)
+	return nil
+}
+
+func (j *CookieJar) add(entries []*CookieEntry) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	now := time.Now()
+	for _, e := range entries {
+		if e.Name != "" && e.Domain != "" && !e.expired(now) {
+			if e.Path == "" {
+				e.Path = "/"
+			}
+			j.entries[e.id()] = e
+			j.replay(e)
+		}
+	}
+}
+
+// rebuild 以保存的记录重建内部的 cookiejar
+func (j *CookieJar) rebuild() {
+	j.jar, _ = cookiejar.New(&cookiejar.Options{PublicSuffixList: j.psl})
+	for _, e := range j.entries {
+		j.replay(e)
+	}
+}
+
+func (j *CookieJar) replay(e *CookieEntry) {
+	u := &url.URL{Scheme: "http", Host: e.Domain, Path: e.Path}
+	if e.Secure {
+		u.Scheme = "https"
+	}
+	cookie := e.Cookie()
+	if e.HostOnly {
+		cookie.Domain = ""
+	}
+	j.jar.SetCookies(u, []*http.Cookie{cookie})
+}
+
+// newEntry 按 RFC 6265 计算 Cookie 的域名、路径和过期时间，不接受的 Cookie 返回 false
+func (j *CookieJar) newEntry(u *url.URL, cookie *http.Cookie, now time.Time) (*CookieEntry, bool) {
+	host := canonicalHost(u.Host)
+	e := &CookieEntry{Name: cookie.Name, Value: cookie.Value, Path: cookie.Path, Secure: cookie.Secure, HttpOnly: cookie.HttpOnly}
+	if e.Name == "" {
+		return nil, false
+	}
+
+	switch domain := canonicalHost(cookie.Domain); {
+	case domain == "":
+		e.Domain, e.HostOnly = host, true
+	case net.ParseIP(host) != nil, j.psl.PublicSuffix(domain) == domain:
+		if domain != host {
+			return nil, false
+		}
+		e.Domain, e.HostOnly = host, true
+	case !domainMatch(host, domain):
+		return nil, false
+	default:
+		e.Domain = domain
+	}
+
+	if e.Path == "" || e.Path[0] != '/' {
+		e.Path = "/"
+		if i := strings.LastIndex(u.Path, "/"); i > 0 {
+			e.Path = u.Path[:i]
+		}
+	}
+
+	switch {
+	case cookie.MaxAge < 0:
+		e.Expires = now
+	case cookie.MaxAge > 0:
+		e.Expires = now.Add(time.Duration(cookie.MaxAge) * time.Second)
+	case !cookie.Expires.IsZero():
+		e.Expires = cookie.Expires
+	}
+	return e, true
+}
+
+// canonicalHost 小写、去掉端口和首尾的点
+func canonicalHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.Trim(strings.ToLower(host), ".[]")
+}
+
+// domainMatch host 是否为 domain 或其子域名
+func domainMatch(host, domain string) bool {
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+func isJSONFile(fn string) bool {
+	return strings.EqualFold(filepath.Ext(fn), ".json")
+}
+
+func netscapeBool(b bool) string {
+	if b {
+		return "TRUE"
+	}
+	return "FALSE"
+}
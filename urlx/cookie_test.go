@@ -0,0 +1,66 @@
+package urlx
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSessionPersist(t *testing.T) {
+	addr, closer := mockHTTPServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" {
+			http.SetCookie(rw, &http.Cookie{Name: "sid", Value: "s1", Path: "/", HttpOnly: true, Expires: time.Now().Add(time.Hour)})
+			http.SetCookie(rw, &http.Cookie{Name: "tmp", Value: "t1"})
+			return
+		}
+		c, _ := r.Cookie("sid")
+		if c != nil {
+			_, _ = rw.Write([]byte(c.Value))
+		}
+	}))
+	defer closer()
+
+	for _, fn := range []string{"cookies.txt", "cookies.json"} {
+		fn = filepath.Join(t.TempDir(), fn)
+		s, err := NewSession(fn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := s.New(context.TODO()).Url(addr + "/login").Process(nil); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Save(); err != nil {
+			t.Fatal(err)
+		}
+
+		s2, err := NewSession(fn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := s2.New(context.TODO()).Url(addr + "/me").Bytes()
+		if err != nil {
+			t.Fatal(err)
+		}
+		eq(t, [][2]any{
+			{string(data), "s1"},
+			{len(s2.Jar().List("127.0.0.1")), 2},
+			{len(s2.Jar().Domains()), 1},
+		})
+
+		s2.Jar().Clear("127.0.0.1")
+		eq(t, [][2]any{{len(s2.Jar().Entries()), 0}})
+	}
+}
+
+func TestCookieJarPublicSuffix(t *testing.T) {
+	jar := NewCookieJar(nil)
+	jar.Set("www.example.co.uk", &http.Cookie{Name: "a", Value: "1", Domain: "co.uk"}, &http.Cookie{Name: "b", Value: "2", Domain: "example.co.uk"})
+	cookies := jar.List("example.co.uk")
+	eq(t, [][2]any{
+		{len(cookies), 1},
+		{cookies[0].Name, "b"},
+		{len(jar.List("co.uk")), 1},
+	})
+}
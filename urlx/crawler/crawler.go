@@ -0,0 +1,454 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/cnk3x/go/urlx"
+	"github.com/cnk3x/go/urlx/htmlquery"
+	"github.com/cnk3x/go/urlx/robots"
+)
+
+var ErrStatus = errors.New("crawler: unexpected status")
+
+type (
+	Handler      = func(page *Page) error                  // 页面处理
+	ErrorHandler = func(item Item, err error)              // 抓取出错
+	NewRequest   = func(ctx context.Context) *urlx.Request // 请求构造
+)
+
+// Page 抓取到的页面
+type Page struct {
+	Item
+	URL      *url.URL           // 最终地址，重定向后可能与 Item.URL 不同
+	Response *http.Response     // 响应
+	Doc      *goquery.Selection // 文档
+}
+
+// Bind 将匹配 find 的节点绑定到 out，见 htmlquery.BindSelection
+func (p *Page) Bind(find string, out any, options ...htmlquery.Options) error {
+	return htmlquery.BindSelection(p.Doc.Find(find), out, options...)
+}
+
+// Crawler 爬虫
+type Crawler struct {
+	newRequest NewRequest
+	workers    int
+	maxDepth   int
+	delay      time.Duration
+	follow     []string
+	rules      rules
+	handlers   []Handler
+	onError    ErrorHandler
+	robots     *robots.Cache
+	agent      string
+	stateFile  string
+	err        error // 设置时的错误，Run 时返回
+
+	mu       sync.Mutex
+	cond     *sync.Cond
+	frontier *frontier
+	paused   bool
+	hosts    map[string]*host
+}
+
+// host 每个主机的抓取间隔和 robots.txt 中匹配的规则组
+type host struct {
+	mu    sync.Mutex
+	last  time.Time
+	group *robots.Group
+}
+
+// New 以请求构造方法创建爬虫，为空时使用 urlx.MacEdge
+func New(newRequest NewRequest) *Crawler {
+	if newRequest == nil {
+		newRequest = urlx.MacEdge
+	}
+	c := &Crawler{
+		newRequest: newRequest,
+		workers:    1,
+		onError:    func(item Item, err error) { log.Printf("抓取出错: %s: %v", item.URL, err) },
+		frontier:   newFrontier(),
+		hosts:      map[string]*host{},
+	}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+// Workers 并发数
+func (c *Crawler) Workers(n int) *Crawler {
+	if n > 0 {
+		c.workers = n
+	}
+	return c
+}
+
+// MaxDepth 最大深度，起始地址深度为 0，0 为不限制
+func (c *Crawler) MaxDepth(depth int) *Crawler {
+	c.maxDepth = depth
+	return c
+}
+
+// Delay 同一主机两次请求之间的最小间隔，robots.txt 中的 Crawl-delay 更大时以其为准
+func (c *Crawler) Delay(delay time.Duration) *Crawler {
+	c.delay = delay
+	return c
+}
+
+// Follow 跟随匹配选择器的节点的 href 或 src 链接
+func (c *Crawler) Follow(selectors ...string) *Crawler {
+	c.follow = append(c.follow, selectors...)
+	return c
+}
+
+// AllowDomains 只抓取这些域名及其子域名
+func (c *Crawler) AllowDomains(domains ...string) *Crawler {
+	c.rules.allowDomains = append(c.rules.allowDomains, lower(domains)...)
+	return c
+}
+
+// DenyDomains 不抓取这些域名及其子域名
+func (c *Crawler) DenyDomains(domains ...string) *Crawler {
+	c.rules.denyDomains = append(c.rules.denyDomains, lower(domains)...)
+	return c
+}
+
+// AllowPaths 只抓取这些路径前缀
+func (c *Crawler) AllowPaths(prefixes ...string) *Crawler {
+	c.rules.allowPaths = append(c.rules.allowPaths, prefixes...)
+	return c
+}
+
+// DenyPaths 不抓取这些路径前缀
+func (c *Crawler) DenyPaths(prefixes ...string) *Crawler {
+	c.rules.denyPaths = append(c.rules.denyPaths, prefixes...)
+	return c
+}
+
+// AllowRegexp 只抓取完整地址匹配这些正则的，正则无效时 Run 返回错误
+func (c *Crawler) AllowRegexp(exprs ...string) *Crawler {
+	c.rules.allowRegexps = append(c.rules.allowRegexps, c.compile(exprs)...)
+	return c
+}
+
+// DenyRegexp 不抓取完整地址匹配这些正则的，正则无效时 Run 返回错误
+func (c *Crawler) DenyRegexp(exprs ...string) *Crawler {
+	c.rules.denyRegexps = append(c.rules.denyRegexps, c.compile(exprs)...)
+	return c
+}
+
+// compile 编译正则，记录第一个错误
+func (c *Crawler) compile(exprs []string) (r []*regexp.Regexp) {
+	for _, expr := range exprs {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			if c.err == nil {
+				c.err = fmt.Errorf("crawler: regexp %q: %w", expr, err)
+			}
+			continue
+		}
+		r = append(r, re)
+	}
+	return
+}
+
+// Robots 遵守 robots.txt，agent 为匹配规则组时使用的 User-agent，为空时使用请求头中的 User-Agent
+func (c *Crawler) Robots(agent string) *Crawler {
+	c.robots = robots.NewCache(c.newRequest, 0)
+	c.agent = agent
+	return c
+}
+
+// State 队列状态文件，暂停或中断时保存，下次运行时从中恢复
+func (c *Crawler) State(fn string) *Crawler {
+	c.stateFile = fn
+	return c
+}
+
+// OnPage 处理抓取到的页面
+func (c *Crawler) OnPage(handler Handler) *Crawler {
+	c.handlers = append(c.handlers, handler)
+	return c
+}
+
+// OnError 处理抓取错误，默认打印日志
+func (c *Crawler) OnError(handler ErrorHandler) *Crawler {
+	c.onError = handler
+	return c
+}
+
+// Run 从起始地址开始抓取，直到队列为空或 ctx 结束
+//
+// 设置了状态文件时，先从中恢复队列，中断后保存，全部完成后删除
+func (c *Crawler) Run(ctx context.Context, seeds ...string) error {
+	if c.err != nil {
+		return c.err
+	}
+	if c.stateFile != "" {
+		c.mu.Lock()
+		err := c.frontier.load(c.stateFile)
+		c.mu.Unlock()
+		if err != nil {
+			return fmt.Errorf("load state: %w", err)
+		}
+	}
+	for _, seed := range seeds {
+		if err := c.Add(seed, 0); err != nil {
+			return err
+		}
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			c.mu.Lock()
+			c.cond.Broadcast()
+			c.mu.Unlock()
+		case <-stop:
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < c.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.work(ctx)
+		}()
+	}
+	wg.Wait()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stateFile == "" {
+		return ctx.Err()
+	}
+	if c.frontier.finished() {
+		if err := os.Remove(c.stateFile); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	if err := c.frontier.save(c.stateFile); err != nil {
+		return fmt.Errorf("save state: %w", err)
+	}
+	return ctx.Err()
+}
+
+// Add 加入待抓取地址，不符合规则或已抓取的忽略
+func (c *Crawler) Add(rawURL string, depth int) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	c.enqueue(u, depth)
+	return nil
+}
+
+// Pause 暂停派发新的地址，等待正在抓取的完成并保存状态
+func (c *Crawler) Pause() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.paused = true
+	for len(c.frontier.inflight) > 0 {
+		c.cond.Wait()
+	}
+	if c.stateFile != "" {
+		return c.frontier.save(c.stateFile)
+	}
+	return nil
+}
+
+// Resume 继续抓取
+func (c *Crawler) Resume() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.paused = false
+	c.cond.Broadcast()
+}
+
+func (c *Crawler) enqueue(u *url.URL, depth int) {
+	u, ok := normalize(u)
+	if !ok || !c.rules.match(u) || (c.maxDepth > 0 && depth > c.maxDepth) {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.frontier.push(Item{URL: u.String(), Depth: depth}) {
+		c.cond.Broadcast()
+	}
+}
+
+// next 取出下一个地址，队列为空时等待其他正在抓取的页面产生新地址
+func (c *Crawler) next(ctx context.Context) (Item, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for ctx.Err() == nil && !c.frontier.finished() && (c.paused || len(c.frontier.queue) == 0) {
+		c.cond.Wait()
+	}
+	if ctx.Err() != nil || len(c.frontier.queue) == 0 {
+		return Item{}, false
+	}
+	return c.frontier.pop(), true
+}
+
+func (c *Crawler) done(item Item) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.frontier.done(item)
+	c.cond.Broadcast()
+}
+
+func (c *Crawler) work(ctx context.Context) {
+	for {
+		item, ok := c.next(ctx)
+		if !ok {
+			return
+		}
+		if err := c.crawl(ctx, item); err != nil {
+			if ctx.Err() != nil {
+				// 中断的地址保留在队列中，下次恢复时重新抓取
+				c.mu.Lock()
+				c.frontier.queue = append([]Item{item}, c.frontier.queue...)
+				c.mu.Unlock()
+			} else if c.onError != nil {
+				c.onError(item, err)
+			}
+		}
+		c.done(item)
+	}
+}
+
+func (c *Crawler) crawl(ctx context.Context, item Item) error {
+	u, err := url.Parse(item.URL)
+	if err != nil {
+		return err
+	}
+
+	h := c.host(u)
+	if c.robots != nil {
+		if g := c.robotsGroup(ctx, h, u); !g.Allowed(u.RequestURI()) {
+			return nil
+		}
+	}
+	if err = h.wait(ctx, c.delay); err != nil {
+		return err
+	}
+	defer h.release()
+
+	return c.newRequest(ctx).Url(item.URL).Process(func(resp *http.Response, body io.ReadCloser) error {
+		defer body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return fmt.Errorf("%w: %s", ErrStatus, resp.Status)
+		}
+		if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get(urlx.HeaderContentType)); mediaType != "" && !strings.Contains(mediaType, "html") {
+			return nil
+		}
+
+		doc, err := goquery.NewDocumentFromReader(body)
+		if err != nil {
+			return fmt.Errorf("read as html: %w", err)
+		}
+		page := &Page{Item: item, URL: resp.Request.URL, Response: resp, Doc: doc.Selection}
+		for _, handler := range c.handlers {
+			if err := handler(page); err != nil {
+				return err
+			}
+		}
+		c.followLinks(page)
+		return nil
+	})
+}
+
+func (c *Crawler) followLinks(page *Page) {
+	for _, selector := range c.follow {
+		page.Doc.Find(selector).Each(func(_ int, s *goquery.Selection) {
+			href, ok := s.Attr("href")
+			if !ok {
+				href, ok = s.Attr("src")
+			}
+			if !ok {
+				return
+			}
+			if u, err := page.URL.Parse(strings.TrimSpace(href)); err == nil {
+				c.enqueue(u, page.Depth+1)
+			}
+		})
+	}
+}
+
+func (c *Crawler) host(u *url.URL) *host {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := u.Scheme + "://" + u.Host
+	h, ok := c.hosts[key]
+	if !ok {
+		h = &host{}
+		c.hosts[key] = h
+	}
+	return h
+}
+
+// robotsGroup 获取主机 robots.txt 中与 agent 匹配的规则组，获取失败时视为全部允许
+func (c *Crawler) robotsGroup(ctx context.Context, h *host, u *url.URL) *robots.Group {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.group != nil {
+		return h.group
+	}
+
+	agent := c.agent
+	if agent == "" {
+		agent = c.newRequest(ctx).Header().Get(urlx.HeaderUserAgent)
+	}
+	r, err := c.robots.Get(ctx, u)
+	if err != nil {
+		r = robots.AllowAll()
+	}
+	h.group = r.Group(agent)
+	return h.group
+}
+
+// wait 独占主机，等待距上次请求满足抓取间隔
+func (h *host) wait(ctx context.Context, delay time.Duration) error {
+	h.mu.Lock()
+	if h.group != nil && h.group.CrawlDelay > delay {
+		delay = h.group.CrawlDelay
+	}
+	if d := time.Until(h.last.Add(delay)); d > 0 {
+		select {
+		case <-ctx.Done():
+			h.mu.Unlock()
+			return ctx.Err()
+		case <-time.After(d):
+		}
+	}
+	return nil
+}
+
+func (h *host) release() {
+	h.last = time.Now()
+	h.mu.Unlock()
+}
+
+func lower(ss []string) []string {
+	r := make([]string, len(ss))
+	for i, s := range ss {
+		r[i] = strings.ToLower(s)
+	}
+	return r
+}
@@ -0,0 +1,190 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cnk3x/go/urlx"
+)
+
+func mockHTTPServer(h http.Handler) (string, func()) {
+	listen, _ := net.Listen("tcp", "127.0.0.1:0")
+	s := &http.Server{Handler: h}
+	go func() { _ = s.Serve(listen) }()
+	return "http:" + "//" + listen.Addr().String(), func() { _ = s.Shutdown(context.TODO()) }
+}
+
+func TestCrawler(t *testing.T) {
+	addr, closer := mockHTTPServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			fmt.Fprint(rw, "User-agent: *\nDisallow: /private\n")
+			return
+		}
+		rw.Header().Set(urlx.HeaderContentType, "text/html; charset=utf-8")
+		fmt.Fprintf(rw, `<html><h1>%s</h1>
+<a class="n" href="/a">a</a><a class="n" href="/b#x">b</a><a class="n" href="/private/c">c</a>
+<a class="n" href="/skip.pdf">pdf</a><a href="/ignored">ignored</a><a class="n" href="https://other.example/">other</a></html>`, r.URL.Path)
+	}))
+	defer closer()
+
+	var mu sync.Mutex
+	var titles []string
+	err := New(urlx.Default).
+		Workers(3).
+		MaxDepth(1).
+		Follow("a.n").
+		AllowDomains("127.0.0.1").
+		DenyRegexp(`\.pdf$`).
+		Robots("").
+		State(filepath.Join(t.TempDir(), "state.json")).
+		OnPage(func(page *Page) error {
+			var title string
+			if err := page.Bind("h1", &title); err != nil {
+				return err
+			}
+			mu.Lock()
+			titles = append(titles, title)
+			mu.Unlock()
+			return nil
+		}).
+		Run(context.TODO(), addr+"/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sort.Strings(titles)
+	if got := strings.Join(titles, ","); got != "/,/a,/b" {
+		t.Fatalf("titles: %s", got)
+	}
+}
+
+// chainSite /p0 到 /p5 依次链接，记录每个页面被请求的次数
+func chainSite(hits map[string]int, mu *sync.Mutex) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits[r.URL.Path]++
+		mu.Unlock()
+		var n int
+		fmt.Sscanf(r.URL.Path, "/p%d", &n)
+		rw.Header().Set(urlx.HeaderContentType, "text/html")
+		if n < 5 {
+			fmt.Fprintf(rw, `<html><h1>%s</h1><a href="/p%d">next</a></html>`, r.URL.Path, n+1)
+		}
+	})
+}
+
+func TestCrawlerResumeState(t *testing.T) {
+	var mu sync.Mutex
+	hits := map[string]int{}
+	addr, closer := mockHTTPServer(chainSite(hits, &mu))
+	defer closer()
+	state := filepath.Join(t.TempDir(), "state.json")
+
+	// 抓取两页后中断，队列保存到状态文件
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pages := 0
+	err := New(urlx.Default).Follow("a").State(state).OnPage(func(page *Page) error {
+		if pages++; pages == 2 {
+			cancel()
+		}
+		return nil
+	}).Run(ctx, addr+"/p0")
+	if err != context.Canceled {
+		t.Fatalf("first run: %v", err)
+	}
+	if _, err = os.Stat(state); err != nil {
+		t.Fatalf("state not saved: %v", err)
+	}
+
+	// 新的爬虫从状态文件继续，已抓取的不再请求，完成后删除状态文件
+	if err = New(urlx.Default).Follow("a").State(state).Run(context.Background(), addr+"/p0"); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i <= 5; i++ {
+		if path := fmt.Sprintf("/p%d", i); hits[path] != 1 {
+			t.Errorf("%s requested %d times", path, hits[path])
+		}
+	}
+	if _, err = os.Stat(state); !os.IsNotExist(err) {
+		t.Fatalf("state should be removed: %v", err)
+	}
+}
+
+func TestCrawlerPause(t *testing.T) {
+	var mu sync.Mutex
+	hits := map[string]int{}
+	addr, closer := mockHTTPServer(chainSite(hits, &mu))
+	defer closer()
+	state := filepath.Join(t.TempDir(), "state.json")
+
+	// 第一个页面处理期间暂停，处理完成后不再派发新的地址
+	first, proceed := make(chan struct{}), make(chan struct{})
+	var once sync.Once
+	c := New(urlx.Default).Follow("a").State(state).OnPage(func(page *Page) error {
+		once.Do(func() {
+			close(first)
+			<-proceed
+		})
+		return nil
+	})
+	done := make(chan error, 1)
+	go func() { done <- c.Run(context.Background(), addr+"/p0") }()
+
+	<-first
+	paused := make(chan error, 1)
+	go func() { paused <- c.Pause() }()
+	for {
+		c.mu.Lock()
+		ok := c.paused
+		c.mu.Unlock()
+		if ok {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(proceed)
+	if err := <-paused; err != nil {
+		t.Fatal(err)
+	}
+	count := func() (n int) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, v := range hits {
+			n += v
+		}
+		return
+	}
+	time.Sleep(50 * time.Millisecond)
+	if got := count(); got != 1 {
+		t.Fatalf("crawled %d pages while paused, want 1", got)
+	}
+	if _, err := os.Stat(state); err != nil {
+		t.Fatalf("state not saved on pause: %v", err)
+	}
+
+	c.Resume()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if got := count(); got != 6 {
+		t.Fatalf("crawled %d pages, want 6", got)
+	}
+}
+
+func TestCrawlerInvalidRegexp(t *testing.T) {
+	err := New(nil).AllowRegexp(`(`).DenyRegexp(`[`).Run(context.Background(), "http://127.0.0.1/")
+	if err == nil || !strings.Contains(err.Error(), `"("`) {
+		t.Fatalf("err = %v", err)
+	}
+}
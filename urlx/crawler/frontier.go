@@ -0,0 +1,106 @@
+package crawler
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Item 待抓取的地址
+type Item struct {
+	URL   string `json:"url"`
+	Depth int    `json:"depth"`
+}
+
+// frontier 待抓取队列和已见地址，暂停或中断时保存到磁盘
+type frontier struct {
+	queue    []Item
+	seen     map[string]bool
+	inflight map[string]Item
+}
+
+type frontierState struct {
+	Pending []Item   `json:"pending"`
+	Seen    []string `json:"seen"`
+}
+
+func newFrontier() *frontier {
+	return &frontier{seen: map[string]bool{}, inflight: map[string]Item{}}
+}
+
+// push 加入队列，已见过的地址忽略
+func (f *frontier) push(item Item) bool {
+	if f.seen[item.URL] {
+		return false
+	}
+	f.seen[item.URL] = true
+	f.queue = append(f.queue, item)
+	return true
+}
+
+func (f *frontier) pop() Item {
+	item := f.queue[0]
+	f.queue = f.queue[1:]
+	f.inflight[item.URL] = item
+	return item
+}
+
+func (f *frontier) done(item Item) {
+	delete(f.inflight, item.URL)
+}
+
+// finished 队列为空且没有正在抓取的地址
+func (f *frontier) finished() bool {
+	return len(f.queue) == 0 && len(f.inflight) == 0
+}
+
+// save 保存状态，正在抓取的地址作为待抓取保存
+func (f *frontier) save(fn string) error {
+	state := frontierState{Pending: make([]Item, 0, len(f.inflight)+len(f.queue))}
+	for _, item := range f.inflight {
+		state.Pending = append(state.Pending, item)
+	}
+	sort.Slice(state.Pending, func(i, j int) bool { return state.Pending[i].URL < state.Pending[j].URL })
+	state.Pending = append(state.Pending, f.queue...)
+	for u := range f.seen {
+		state.Seen = append(state.Seen, u)
+	}
+	sort.Strings(state.Seen)
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(fn), 0755); err != nil {
+		return err
+	}
+	tempFn := fn + ".crawler_temp"
+	if err = os.WriteFile(tempFn, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tempFn, fn)
+}
+
+// load 加载状态，文件不存在时不报错
+func (f *frontier) load(fn string) error {
+	data, err := os.ReadFile(fn)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	var state frontierState
+	if err = json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+	for _, u := range state.Seen {
+		f.seen[u] = true
+	}
+	for _, item := range state.Pending {
+		f.seen[item.URL] = true
+		f.queue = append(f.queue, item)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package crawler
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// rules 地址过滤规则，拒绝规则优先
+type rules struct {
+	allowDomains []string
+	denyDomains  []string
+	allowPaths   []string
+	denyPaths    []string
+	allowRegexps []*regexp.Regexp
+	denyRegexps  []*regexp.Regexp
+}
+
+func (r *rules) match(u *url.URL) bool {
+	host := strings.ToLower(u.Hostname())
+	if matchDomain(host, r.denyDomains) || matchPath(u.Path, r.denyPaths) || matchRegexp(u.String(), r.denyRegexps) {
+		return false
+	}
+	if len(r.allowDomains) > 0 && !matchDomain(host, r.allowDomains) {
+		return false
+	}
+	if len(r.allowPaths) > 0 && !matchPath(u.Path, r.allowPaths) {
+		return false
+	}
+	if len(r.allowRegexps) > 0 && !matchRegexp(u.String(), r.allowRegexps) {
+		return false
+	}
+	return true
+}
+
+// matchDomain 域名或其子域名
+func matchDomain(host string, domains []string) bool {
+	for _, domain := range domains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPath 路径前缀
+func matchPath(path string, prefixes []string) bool {
+	if path == "" {
+		path = "/"
+	}
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchRegexp(s string, exprs []*regexp.Regexp) bool {
+	for _, expr := range exprs {
+		if expr.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalize 去掉片段，小写主机，空路径补 "/"，只接受 http 和 https
+func normalize(u *url.URL) (*url.URL, bool) {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, false
+	}
+	n := *u
+	n.Fragment, n.RawFragment = "", ""
+	n.Host = strings.ToLower(n.Host)
+	if n.Path == "" {
+		n.Path = "/"
+	}
+	return &n, true
+}
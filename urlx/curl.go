@@ -0,0 +1,677 @@
+package urlx
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var ErrCurl = errors.New("curl: invalid command")
+
+const HeaderAuthorization = "Authorization"
+
+// curl 参数，值为 true 的带参数
+var curlFlags = map[string]bool{
+	"-X": true, "--request": true,
+	"-H": true, "--header": true,
+	"-d": true, "--data": true, "--data-raw": true, "--data-binary": true, "--data-ascii": true, "--data-urlencode": true,
+	"-F": true, "--form": true, "--form-string": true,
+	"-b": true, "--cookie": true,
+	"-u": true, "--user": true,
+	"-A": true, "--user-agent": true,
+	"-e": true, "--referer": true,
+	"-x": true, "--proxy": true, "--url": true, "--max-redirs": true,
+	"-m": true, "--max-time": true, "--connect-timeout": true,
+	"-o": true, "--output": true,
+	"-G": false, "--get": false, "-I": false, "--head": false,
+	"-k": false, "--insecure": false, "--compressed": false,
+	"-L": false, "--location": false, "--http1.1": false, "--http2": false,
+	"-s": false, "--silent": false, "-S": false, "--show-error": false,
+	"-v": false, "--verbose": false, "-i": false, "--include": false, "-f": false, "--fail": false,
+	"-g": false, "--globoff": false,
+}
+
+// curlSwitches 不带参数的选项
+type curlSwitches struct {
+	method     string
+	get        bool
+	compressed bool
+	insecure   bool
+	location   bool
+	http1      bool
+}
+
+// curlForm -F 的一项
+type curlForm struct {
+	name, value string
+	file        string // @ 上传的文件
+	contentType string
+}
+
+// FromCurl 解析浏览器开发者工具复制的 curl 命令 (bash 格式)，构造等价的请求
+//
+// 支持 -X、-H、-d/--data/--data-raw/--data-binary/--data-urlencode、-F、-b、-u、-A、-e、-G、-I、--compressed、-k、
+// -L、--max-redirs、-x、-m、--connect-timeout、--http1.1 和 --http2。
+// 与 curl 一样，没有 -L 时不跟随重定向。-s、-S、-v、-i、-o、-f、-g 只影响输出，忽略；其余选项返回 ErrCurl
+func FromCurl(cmd string) (*Request, error) {
+	args, err := splitCurl(cmd)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) > 0 && args[0] == "curl" {
+		args = args[1:]
+	}
+
+	var (
+		rawUrl         string
+		sw             curlSwitches
+		headers        = http.Header{}
+		data           []string
+		forms          []curlForm
+		cookieFile     string
+		maxRedirs      = -1
+		proxy          *url.URL
+		maxTime        time.Duration
+		connectTimeout time.Duration
+	)
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			rawUrl = arg
+			continue
+		}
+
+		// 合并的短参数，如 -sSL、-XPOST
+		name, value, hasValue := arg, "", false
+		if !strings.HasPrefix(arg, "--") && len(arg) > 2 {
+			for j := 1; j < len(arg); j++ {
+				short := "-" + arg[j:j+1]
+				withArg, ok := curlFlags[short]
+				if !ok {
+					return nil, fmt.Errorf("%w: unknown option %s", ErrCurl, short)
+				}
+				if withArg {
+					name, value, hasValue = short, arg[j+1:], j+1 < len(arg)
+					break
+				}
+				name = short
+				if j < len(arg)-1 {
+					if err = sw.apply(short); err != nil {
+						return nil, err
+					}
+				}
+			}
+		}
+
+		withArg, ok := curlFlags[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown option %s", ErrCurl, name)
+		}
+		if !withArg {
+			if err = sw.apply(name); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if !hasValue {
+			if i++; i >= len(args) {
+				return nil, fmt.Errorf("%w: option %s requires a value", ErrCurl, name)
+			}
+			value = args[i]
+		}
+
+		switch name {
+		case "-X", "--request":
+			sw.method = strings.ToUpper(value)
+		case "-H", "--header":
+			if k, v, ok := cutString(value, ":"); ok {
+				headers.Add(strings.TrimSpace(k), strings.TrimSpace(v))
+			} else if k, _, ok := cutString(value, ";"); ok {
+				headers.Add(strings.TrimSpace(k), "")
+			}
+		case "-d", "--data", "--data-ascii", "--data-binary":
+			if strings.HasPrefix(value, "@") {
+				content, err := os.ReadFile(value[1:])
+				if err != nil {
+					return nil, err
+				}
+				if name != "--data-binary" {
+					content = bytes.ReplaceAll(bytes.ReplaceAll(content, []byte("\r"), nil), []byte("\n"), nil)
+				}
+				value = string(content)
+			}
+			data = append(data, value)
+		case "--data-raw":
+			data = append(data, value)
+		case "--data-urlencode":
+			encoded, err := curlURLEncode(value)
+			if err != nil {
+				return nil, err
+			}
+			data = append(data, encoded)
+		case "-F", "--form", "--form-string":
+			form, err := parseCurlForm(value, name == "--form-string")
+			if err != nil {
+				return nil, err
+			}
+			forms = append(forms, form)
+		case "-b", "--cookie":
+			if strings.Contains(value, "=") {
+				headers.Add("Cookie", value)
+			} else {
+				cookieFile = value
+			}
+		case "-u", "--user":
+			headers.Set(HeaderAuthorization, "Basic "+base64.StdEncoding.EncodeToString([]byte(value)))
+		case "-A", "--user-agent":
+			headers.Set(HeaderUserAgent, value)
+		case "-e", "--referer":
+			headers.Set(HeaderReferer, value)
+		case "--url":
+			rawUrl = value
+		case "-x", "--proxy":
+			if !strings.Contains(value, "://") {
+				value = "http://" + value
+			}
+			if proxy, err = url.Parse(value); err != nil {
+				return nil, fmt.Errorf("%w: %s %v", ErrCurl, name, err)
+			}
+		case "--max-redirs":
+			if maxRedirs, err = strconv.Atoi(value); err != nil {
+				return nil, fmt.Errorf("%w: %s %q", ErrCurl, name, value)
+			}
+		case "-m", "--max-time":
+			if maxTime, err = curlSeconds(value); err != nil {
+				return nil, fmt.Errorf("%w: %s %q", ErrCurl, name, value)
+			}
+		case "--connect-timeout":
+			if connectTimeout, err = curlSeconds(value); err != nil {
+				return nil, fmt.Errorf("%w: %s %q", ErrCurl, name, value)
+			}
+		}
+	}
+
+	if rawUrl == "" {
+		return nil, fmt.Errorf("%w: missing url", ErrCurl)
+	}
+	if !strings.Contains(rawUrl, "://") {
+		rawUrl = "http://" + rawUrl
+	}
+	if _, err = url.Parse(rawUrl); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCurl, err)
+	}
+
+	c := New(nil).Url(rawUrl)
+	switch {
+	case len(data) > 0 && sw.get:
+		c.Query(strings.Join(data, "&"))
+	case len(data) > 0:
+		body := strings.Join(data, "&")
+		c.SendBody(func() (string, io.Reader, error) {
+			return "application/x-www-form-urlencoded", strings.NewReader(body), nil
+		})
+		if sw.method == "" {
+			sw.method = http.MethodPost
+		}
+	case len(forms) > 0:
+		c.SendBody(curlMultipart(forms))
+		if sw.method == "" {
+			sw.method = http.MethodPost
+		}
+	}
+	if sw.method != "" {
+		c.Method(sw.method)
+	}
+
+	// 没有 --max-redirs 或为 -1 (curl 的不限制) 时使用默认的次数
+	switch {
+	case !sw.location:
+		c.NoRedirect()
+	case maxRedirs >= 0:
+		c.MaxRedirects(maxRedirs)
+	}
+	if maxTime > 0 {
+		c.Timeout(maxTime)
+	}
+
+	if sw.compressed {
+		if headers.Get(HeaderAcceptEncoding) == "" {
+			headers.Set(HeaderAcceptEncoding, "deflate, gzip, br, zstd")
+		}
+		c.ProcessWith(DecompressionBody)
+	}
+	if len(headers) > 0 {
+		c.HeaderWith(func(h http.Header) {
+			for k, vs := range headers {
+				h[k] = append([]string(nil), vs...)
+			}
+		})
+	}
+
+	if sw.insecure || sw.http1 || proxy != nil || connectTimeout > 0 || cookieFile != "" {
+		client := &http.Client{}
+		if sw.insecure || sw.http1 || proxy != nil || connectTimeout > 0 {
+			transport := http.DefaultTransport.(*http.Transport).Clone()
+			if sw.insecure {
+				transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+			}
+			if sw.http1 {
+				transport.ForceAttemptHTTP2 = false
+				transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+			}
+			if proxy != nil {
+				transport.Proxy = http.ProxyURL(proxy)
+			}
+			if connectTimeout > 0 {
+				transport.DialContext = (&net.Dialer{Timeout: connectTimeout, KeepAlive: 30 * time.Second}).DialContext
+			}
+			client.Transport = transport
+		}
+		if cookieFile != "" {
+			jar := NewCookieJar(nil)
+			if err = jar.Load(cookieFile); err != nil {
+				return nil, err
+			}
+			client.Jar = jar
+		}
+		c.UseClient(client)
+	}
+	return c, nil
+}
+
+func (sw *curlSwitches) apply(name string) error {
+	switch name {
+	case "-G", "--get":
+		sw.get = true
+	case "-I", "--head":
+		sw.method = http.MethodHead
+	case "--compressed":
+		sw.compressed = true
+	case "-k", "--insecure":
+		sw.insecure = true
+	case "-L", "--location":
+		sw.location = true
+	case "--http1.1":
+		sw.http1 = true
+	case "--http2":
+		sw.http1 = false
+	default:
+		if _, ok := curlFlags[name]; !ok {
+			return fmt.Errorf("%w: unknown option %s", ErrCurl, name)
+		}
+	}
+	return nil
+}
+
+// curlSeconds -m 和 --connect-timeout 的秒数，可以是小数
+func curlSeconds(value string) (time.Duration, error) {
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil || seconds < 0 {
+		return 0, fmt.Errorf("invalid seconds %q", value)
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// curlURLEncode --data-urlencode 的几种格式: content、=content、name=content、@file、name@file
+func curlURLEncode(value string) (string, error) {
+	if i := strings.IndexAny(value, "=@"); i >= 0 {
+		name, content := value[:i], value[i+1:]
+		if value[i] == '@' {
+			data, err := os.ReadFile(content)
+			if err != nil {
+				return "", err
+			}
+			content = string(data)
+		}
+		if name == "" {
+			return url.QueryEscape(content), nil
+		}
+		return name + "=" + url.QueryEscape(content), nil
+	}
+	return url.QueryEscape(value), nil
+}
+
+// parseCurlForm -F name=value、name=@file;type=mime、name=<file
+func parseCurlForm(value string, literal bool) (form curlForm, err error) {
+	name, v, ok := cutString(value, "=")
+	if !ok {
+		return form, fmt.Errorf("%w: illegal form %q", ErrCurl, value)
+	}
+	form.name = name
+	if literal || v == "" || (v[0] != '@' && v[0] != '<') {
+		form.value = v
+		return
+	}
+
+	path := v[1:]
+	if i := strings.Index(path, ";"); i >= 0 {
+		for _, attr := range strings.Split(path[i+1:], ";") {
+			if k, x, ok := cutString(attr, "="); ok && strings.TrimSpace(k) == "type" {
+				form.contentType = strings.TrimSpace(x)
+			}
+		}
+		path = path[:i]
+	}
+	if v[0] == '<' {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return form, err
+		}
+		form.value = string(data)
+		return form, nil
+	}
+	form.file = path
+	return form, nil
+}
+
+// curlMultipart 每次构造时重新打开文件，可以重试
+func curlMultipart(forms []curlForm) Body {
+	return func() (contentType string, body io.Reader, err error) {
+		var buf bytes.Buffer
+		mw := multipart.NewWriter(&buf)
+		for _, form := range forms {
+			if form.file == "" {
+				if err = mw.WriteField(form.name, form.value); err != nil {
+					return
+				}
+				continue
+			}
+
+			h := textproto.MIMEHeader{}
+			h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, escapeQuotes(form.name), escapeQuotes(filepath.Base(form.file))))
+			ct := form.contentType
+			if ct == "" {
+				ct = "application/octet-stream"
+			}
+			h.Set(HeaderContentType, ct)
+			var part io.Writer
+			if part, err = mw.CreatePart(h); err != nil {
+				return
+			}
+			var data []byte
+			if data, err = os.ReadFile(form.file); err != nil {
+				return
+			}
+			if _, err = part.Write(data); err != nil {
+				return
+			}
+		}
+		if err = mw.Close(); err != nil {
+			return
+		}
+		return mw.FormDataContentType(), &buf, nil
+	}
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
+
+func cutString(s, sep string) (before, after string, found bool) {
+	if i := strings.Index(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
+
+// splitCurl 按 bash 的规则分割命令，支持单引号、双引号、$'...' 和行尾的 \ 续行
+func splitCurl(s string) (args []string, err error) {
+	var (
+		cur    strings.Builder
+		inWord bool
+	)
+	flush := func() {
+		if inWord {
+			args = append(args, cur.String())
+			cur.Reset()
+			inWord = false
+		}
+	}
+
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		switch {
+		case ch == '\\':
+			if i+1 < len(s) {
+				i++
+				if s[i] == '\r' && i+1 < len(s) && s[i+1] == '\n' {
+					i++
+				}
+				if s[i] != '\n' {
+					cur.WriteByte(s[i])
+					inWord = true
+				}
+			}
+		case ch == '\'':
+			j := strings.IndexByte(s[i+1:], '\'')
+			if j < 0 {
+				return nil, fmt.Errorf("%w: unterminated quote", ErrCurl)
+			}
+			cur.WriteString(s[i+1 : i+1+j])
+			inWord, i = true, i+1+j
+		case ch == '$' && i+1 < len(s) && s[i+1] == '\'':
+			n, err := unquoteANSI(s[i+2:], &cur)
+			if err != nil {
+				return nil, err
+			}
+			inWord, i = true, i+2+n
+		case ch == '"':
+			i++
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) && strings.IndexByte("$`\"\\\n", s[i+1]) >= 0 {
+					i++
+					if s[i] == '\n' {
+						continue
+					}
+				}
+				cur.WriteByte(s[i])
+			}
+			if i >= len(s) {
+				return nil, fmt.Errorf("%w: unterminated quote", ErrCurl)
+			}
+			inWord = true
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			flush()
+		default:
+			cur.WriteByte(ch)
+			inWord = true
+		}
+	}
+	flush()
+	return
+}
+
+// unquoteANSI 解码 $'...' 的内容，返回包含结尾引号在内消耗的字节数
+func unquoteANSI(s string, w *strings.Builder) (int, error) {
+	simple := map[byte]byte{'a': '\a', 'b': '\b', 'e': 0x1b, 'E': 0x1b, 'f': '\f', 'n': '\n', 'r': '\r', 't': '\t', 'v': '\v', '\\': '\\', '\'': '\'', '"': '"', '?': '?'}
+	for i := 0; i < len(s); i++ {
+		switch ch := s[i]; {
+		case ch == '\'':
+			return i + 1, nil
+		case ch == '\\' && i+1 < len(s):
+			i++
+			if r, ok := simple[s[i]]; ok {
+				w.WriteByte(r)
+				continue
+			}
+			var digits, base, bits int
+			switch s[i] {
+			case 'x':
+				digits, base, bits = 2, 16, 8
+			case 'u':
+				digits, base, bits = 4, 16, 32
+			case 'U':
+				digits, base, bits = 8, 16, 32
+			case '0', '1', '2', '3', '4', '5', '6', '7':
+				digits, base, bits = 3, 8, 8
+				i--
+			default:
+				w.WriteByte('\\')
+				w.WriteByte(s[i])
+				continue
+			}
+			j := i + 1
+			for j < len(s) && j < i+1+digits && isDigitOf(s[j], base) {
+				j++
+			}
+			v, err := strconv.ParseUint(s[i+1:j], base, bits)
+			if err != nil {
+				return 0, fmt.Errorf("%w: invalid escape \\%s", ErrCurl, s[i:j])
+			}
+			if bits == 8 {
+				w.WriteByte(byte(v))
+			} else {
+				w.WriteRune(rune(v))
+			}
+			i = j - 1
+		default:
+			w.WriteByte(ch)
+		}
+	}
+	return 0, fmt.Errorf("%w: unterminated quote", ErrCurl)
+}
+
+func isDigitOf(ch byte, base int) bool {
+	if base == 8 {
+		return ch >= '0' && ch <= '7'
+	}
+	return (ch >= '0' && ch <= '9') || (ch >= 'a' && ch <= 'f') || (ch >= 'A' && ch <= 'F')
+}
+
+// Curl 输出等价的 curl 命令，用于调试
+//
+// 会应用选项 (不修改请求本身) 并构造一次请求内容，一次性的 io.Reader 内容在这之后不能再发送
+func (c *Request) Curl() string {
+	r, _ := c.applied()
+
+	var b strings.Builder
+	b.WriteString("curl")
+	method := r.method
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	var body []byte
+	var contentType string
+	if r.buildBody != nil {
+		if ct, reader, err := r.buildBody(); err == nil && reader != nil {
+			contentType = ct
+			body, _ = io.ReadAll(reader)
+		}
+	}
+
+	switch {
+	case method == http.MethodHead:
+		b.WriteString(" -I")
+	case method == http.MethodGet && body == nil, method == http.MethodPost && body != nil:
+	default:
+		b.WriteString(" -X " + method)
+	}
+	b.WriteString(" " + shellQuote(r.RequestURL()))
+
+	headers := r.Header()
+	if contentType != "" && headers.Get(HeaderContentType) == "" {
+		headers.Set(HeaderContentType, contentType)
+	}
+	if r.client != nil && r.client.Jar != nil {
+		if u, err := url.Parse(r.RequestURL()); err == nil {
+			for _, cookie := range r.client.Jar.Cookies(u) {
+				headers.Add("Cookie", cookie.String())
+			}
+		}
+	}
+	keys := make([]string, 0, len(headers))
+	for k := range headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range headers[k] {
+			if v == "" {
+				b.WriteString(" -H " + shellQuote(k+";"))
+			} else {
+				b.WriteString(" -H " + shellQuote(k+": "+v))
+			}
+		}
+	}
+
+	if body != nil {
+		b.WriteString(" --data-raw " + shellQuote(string(body)))
+	}
+	if !r.redirect.noFollow {
+		b.WriteString(" -L")
+		if r.redirect.max > 0 {
+			b.WriteString(" --max-redirs " + strconv.Itoa(r.redirect.max))
+		}
+	}
+	if r.timeouts.overall > 0 {
+		b.WriteString(" -m " + strconv.FormatFloat(r.timeouts.overall.Seconds(), 'f', -1, 64))
+	}
+	if r.client != nil {
+		if t, ok := r.client.Transport.(*http.Transport); ok && t.TLSClientConfig != nil && t.TLSClientConfig.InsecureSkipVerify {
+			b.WriteString(" -k")
+		}
+	}
+	return b.String()
+}
+
+// shellQuote bash 的引号，含不可打印字符时使用 $'...'
+func shellQuote(s string) string {
+	if s == "" {
+		return "''"
+	}
+	printable := utf8.ValidString(s)
+	for _, r := range s {
+		if r < 0x20 || r == 0x7f {
+			printable = false
+			break
+		}
+	}
+	if printable {
+		return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+	}
+
+	var b strings.Builder
+	b.WriteString("$'")
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			fmt.Fprintf(&b, `\x%02x`, s[i])
+		case r == '\'' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&b, `\x%02x`, r)
+		default:
+			b.WriteRune(r)
+		}
+		i += size
+	}
+	b.WriteString("'")
+	return b.String()
+}
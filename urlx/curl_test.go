@@ -0,0 +1,174 @@
+package urlx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func curlEcho(t *testing.T) (string, func()) {
+	return mockHTTPServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		// /r3 依次重定向到 /r2、/r1、/r0
+		var n int
+		if _, err := fmt.Sscanf(r.URL.Path, "/r%d", &n); err == nil && n > 0 {
+			http.Redirect(rw, r, fmt.Sprintf("/r%d", n-1), http.StatusFound)
+			return
+		}
+		if r.URL.Path == "/slow" {
+			time.Sleep(200 * time.Millisecond)
+		}
+		out := map[string]string{
+			"method": r.Method,
+			"query":  r.URL.RawQuery,
+			"ct":     r.Header.Get(HeaderContentType),
+			"cookie": r.Header.Get("Cookie"),
+			"x":      r.Header.Get("X-Test"),
+			"ua":     r.Header.Get(HeaderUserAgent),
+		}
+		if user, pass, ok := r.BasicAuth(); ok {
+			out["auth"] = user + ":" + pass
+		}
+		if strings.HasPrefix(out["ct"], "multipart/") {
+			_ = r.ParseMultipartForm(1 << 20)
+			out["field"] = r.FormValue("name")
+			if f, h, err := r.FormFile("file"); err == nil {
+				data, _ := io.ReadAll(f)
+				out["file"] = h.Filename + ":" + h.Header.Get(HeaderContentType) + ":" + string(data)
+			}
+		} else {
+			data, _ := io.ReadAll(r.Body)
+			out["body"] = string(data)
+		}
+		_ = json.NewEncoder(rw).Encode(out)
+	}))
+}
+
+func TestFromCurl(t *testing.T) {
+	addr, closer := curlEcho(t)
+	defer closer()
+
+	cmd := "curl '" + addr + "/api?a=1' \\\n" +
+		"  -H 'accept: application/json' \\\n" +
+		"  -H \"X-Test: it's\" \\\n" +
+		"  -H 'user-agent: Mozilla/5.0' \\\n" +
+		"  -b 'sid=abc; theme=dark' \\\n" +
+		"  --data-raw $'{\"name\":\"\\u4f60\\'s\"}' \\\n" +
+		"  --compressed -sS"
+	req, err := FromCurl(cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out map[string]string
+	_, err = req.JSON(&out)
+	eq(t, [][2]any{
+		{err, nil},
+		{out["method"], "POST"},
+		{out["query"], "a=1"},
+		{out["x"], "it's"},
+		{out["ua"], "Mozilla/5.0"},
+		{out["cookie"], "sid=abc; theme=dark"},
+		{out["ct"], "application/x-www-form-urlencoded"},
+		{out["body"], `{"name":"你's"}`},
+	})
+
+	req, err = FromCurl("curl -XPUT -u bob:secret " + addr + " -d a=1 --data-urlencode 'b=x y' -H 'Content-Type: text/plain'")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = req.JSON(&out)
+	eq(t, [][2]any{{err, nil}, {out["method"], "PUT"}, {out["auth"], "bob:secret"}, {out["body"], "a=1&b=x+y"}, {out["ct"], "text/plain"}})
+
+	req, err = FromCurl("curl -G " + addr + " -d q=go --data-urlencode 'lang=中文'")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = req.JSON(&out)
+	eq(t, [][2]any{{err, nil}, {out["method"], "GET"}, {out["query"], "q=go&lang=%E4%B8%AD%E6%96%87"}})
+
+	fn := filepath.Join(t.TempDir(), "a.txt")
+	_ = os.WriteFile(fn, []byte("hello"), 0644)
+	req, err = FromCurl("curl -k " + addr + " -F name=gopher -F 'file=@" + fn + ";type=text/plain'")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = req.JSON(&out)
+	eq(t, [][2]any{{err, nil}, {out["method"], "POST"}, {out["field"], "gopher"}, {out["file"], "a.txt:text/plain:hello"}})
+
+	for _, bad := range []string{"curl", "curl -Z http://x", "curl 'http://x", "curl -H", "curl --http2-prior-knowledge http://x", "curl -m soon http://x", "curl -L --max-redirs x http://x"} {
+		if _, err = FromCurl(bad); err == nil {
+			t.Fatalf("%s: want error", bad)
+		}
+	}
+}
+
+func TestFromCurlTransfer(t *testing.T) {
+	addr, closer := curlEcho(t)
+	defer closer()
+
+	status := func(cmd string) (int, error) {
+		req, err := FromCurl(cmd)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var code int
+		err = req.Process(func(resp *http.Response, body io.ReadCloser) error {
+			code = resp.StatusCode
+			return nil
+		})
+		return code, err
+	}
+
+	// 与 curl 一样，没有 -L 时不跟随重定向
+	code, err := status("curl " + addr + "/r1")
+	eq(t, [][2]any{{err, nil}, {code, http.StatusFound}})
+	code, err = status("curl -sL " + addr + "/r3")
+	eq(t, [][2]any{{err, nil}, {code, http.StatusOK}})
+	_, err = status("curl -L --max-redirs 2 " + addr + "/r3")
+	eq(t, [][2]any{{errors.Is(err, ErrTooManyRedirects), true}})
+
+	_, err = status("curl -m 0.05 " + addr + "/slow")
+	eq(t, [][2]any{{errors.Is(err, ErrTimeout), true}})
+
+	var proxied string
+	proxy, closeProxy := mockHTTPServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+	}))
+	defer closeProxy()
+	code, err = status("curl -x " + strings.TrimPrefix(proxy, "http://") + " --connect-timeout 1 --http1.1 http://example.invalid/p")
+	eq(t, [][2]any{{err, nil}, {code, http.StatusOK}, {proxied, "http://example.invalid/p"}})
+}
+
+func TestCurl(t *testing.T) {
+	req := New(nil).Url("http://example.com/a").Query("b=1").Method(MethodPost).
+		HeaderWith(HeaderSet("X-Test", "it's"), UserAgent("ua")).
+		SendJSON(struct {
+			K string `json:"k"`
+		}{"v\n"})
+	cmd := req.Curl()
+	eq(t, [][2]any{{cmd, `curl 'http://example.com/a?b=1' -H 'Content-Type: application/json; charset=utf-8' -H 'User-Agent: ua' -H 'X-Test: it'\''s' --data-raw '{"k":"v\n"}' -L`}})
+
+	parsed, err := FromCurl(cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	eq(t, [][2]any{{parsed.Curl(), cmd}})
+
+	eq(t, [][2]any{
+		{New(nil).Url("http://x").Method(MethodDelete).Curl(), "curl -X DELETE 'http://x' -L"},
+		{New(nil).Url("http://x").SendBody(func() (string, io.Reader, error) { return "", strings.NewReader("a\x00b"), nil }).Curl(), `curl -X GET 'http://x' --data-raw $'a\x00b' -L`},
+		{New(nil).Url("http://x").NoRedirect().Curl(), "curl 'http://x'"},
+		{New(nil).Url("http://x").MaxRedirects(3).Timeout(1500 * time.Millisecond).Curl(), "curl 'http://x' -L --max-redirs 3 -m 1.5"},
+	})
+
+	for _, cmd := range []string{"curl 'http://x'", "curl 'http://x' -L --max-redirs 3 -m 1.5"} {
+		parsed, err := FromCurl(cmd)
+		eq(t, [][2]any{{err, nil}, {parsed.Curl(), cmd}})
+	}
+}
@@ -0,0 +1,266 @@
+package feed
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/cnk3x/go/urlx"
+	"golang.org/x/net/html/charset"
+)
+
+var ErrUnknownFeed = errors.New("feed: neither rss nor atom")
+
+// RSS RSS 2.0
+type RSS struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel RSSChannel `xml:"channel"`
+}
+
+// RSSChannel RSS 频道
+type RSSChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	Language      string    `xml:"language"`
+	PubDate       Time      `xml:"pubDate"`
+	LastBuildDate Time      `xml:"lastBuildDate"`
+	TTL           int       `xml:"ttl"`
+	Items         []RSSItem `xml:"item"`
+}
+
+// RSSItem RSS 条目
+type RSSItem struct {
+	Title       string         `xml:"title"`
+	Link        string         `xml:"link"`
+	Description string         `xml:"description"`
+	Content     string         `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	Author      string         `xml:"author"`
+	Creator     string         `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Categories  []string       `xml:"category"`
+	GUID        string         `xml:"guid"`
+	PubDate     Time           `xml:"pubDate"`
+	Enclosures  []RSSEnclosure `xml:"enclosure"`
+}
+
+// RSSEnclosure RSS 附件
+type RSSEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int64  `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+// Atom Atom 订阅
+type Atom struct {
+	XMLName  xml.Name    `xml:"feed"`
+	ID       string      `xml:"id"`
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle"`
+	Updated  Time        `xml:"updated"`
+	Links    []AtomLink  `xml:"link"`
+	Authors  []AtomName  `xml:"author"`
+	Entries  []AtomEntry `xml:"entry"`
+}
+
+// AtomEntry Atom 条目
+type AtomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Summary    string         `xml:"summary"`
+	Content    string         `xml:"content"`
+	Published  Time           `xml:"published"`
+	Updated    Time           `xml:"updated"`
+	Links      []AtomLink     `xml:"link"`
+	Authors    []AtomName     `xml:"author"`
+	Categories []AtomCategory `xml:"category"`
+}
+
+// AtomLink Atom 链接
+type AtomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+}
+
+// AtomName Atom 作者
+type AtomName struct {
+	Name  string `xml:"name"`
+	Email string `xml:"email"`
+	URI   string `xml:"uri"`
+}
+
+// AtomCategory Atom 分类
+type AtomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+// Link 取 rel 为 alternate 或为空的链接
+func (a Atom) Link() string {
+	return alternate(a.Links)
+}
+
+// Link 取 rel 为 alternate 或为空的链接
+func (e AtomEntry) Link() string {
+	return alternate(e.Links)
+}
+
+func alternate(links []AtomLink) string {
+	for _, link := range links {
+		if link.Rel == "" || link.Rel == "alternate" {
+			return link.Href
+		}
+	}
+	return ""
+}
+
+// Feed RSS 或 Atom 统一后的订阅
+type Feed struct {
+	Title   string
+	Link    string
+	Updated Time
+	Items   []Item
+}
+
+// Item 统一后的条目
+type Item struct {
+	ID        string
+	Title     string
+	Link      string
+	Summary   string
+	Content   string
+	Author    string
+	Published Time
+	Updated   Time
+}
+
+// ToFeed 转换为统一的订阅
+func (r RSS) ToFeed() *Feed {
+	ch := r.Channel
+	f := &Feed{Title: ch.Title, Link: ch.Link, Updated: ch.LastBuildDate}
+	if f.Updated.IsZero() {
+		f.Updated = ch.PubDate
+	}
+	for _, it := range ch.Items {
+		item := Item{ID: it.GUID, Title: it.Title, Link: it.Link, Summary: it.Description, Content: it.Content, Author: it.Author, Published: it.PubDate, Updated: it.PubDate}
+		if item.ID == "" {
+			item.ID = it.Link
+		}
+		if item.Author == "" {
+			item.Author = it.Creator
+		}
+		f.Items = append(f.Items, item)
+	}
+	return f
+}
+
+// ToFeed 转换为统一的订阅
+func (a Atom) ToFeed() *Feed {
+	f := &Feed{Title: a.Title, Link: a.Link(), Updated: a.Updated}
+	for _, e := range a.Entries {
+		item := Item{ID: e.ID, Title: e.Title, Link: e.Link(), Summary: e.Summary, Content: e.Content, Published: e.Published, Updated: e.Updated}
+		if len(e.Authors) > 0 {
+			item.Author = e.Authors[0].Name
+		} else if len(a.Authors) > 0 {
+			item.Author = a.Authors[0].Name
+		}
+		if item.Published.IsZero() {
+			item.Published = e.Updated
+		}
+		f.Items = append(f.Items, item)
+	}
+	return f
+}
+
+// RSSOf 解析 RSS 2.0 响应
+func RSSOf(out *RSS) urlx.Process {
+	return decode(out)
+}
+
+// AtomOf 解析 Atom 响应
+func AtomOf(out *Atom) urlx.Process {
+	return decode(out)
+}
+
+// FeedOf 解析 RSS 2.0 或 Atom 响应，统一为 Feed
+func FeedOf(out *Feed) urlx.Process {
+	return func(resp *http.Response, body io.ReadCloser) error {
+		defer body.Close()
+		r, err := gunzip(resp, body)
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+
+		root, err := rootName(data)
+		if err != nil {
+			return err
+		}
+		switch root {
+		case "rss":
+			var rss RSS
+			if err = newDecoder(bytes.NewReader(data)).Decode(&rss); err == nil {
+				*out = *rss.ToFeed()
+			}
+		case "feed":
+			var atom Atom
+			if err = newDecoder(bytes.NewReader(data)).Decode(&atom); err == nil {
+				*out = *atom.ToFeed()
+			}
+		default:
+			err = fmt.Errorf("%w: <%s>", ErrUnknownFeed, root)
+		}
+		return err
+	}
+}
+
+// decode 解码 XML 响应，gzip 压缩的内容(如 .xml.gz)先解压
+func decode(out any) urlx.Process {
+	return func(resp *http.Response, body io.ReadCloser) error {
+		defer body.Close()
+		r, err := gunzip(resp, body)
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		return newDecoder(r).Decode(out)
+	}
+}
+
+func newDecoder(r io.Reader) *xml.Decoder {
+	dec := xml.NewDecoder(r)
+	dec.CharsetReader = charset.NewReaderLabel
+	return dec
+}
+
+// rootName 文档根节点的名称
+func rootName(data []byte) (string, error) {
+	dec := newDecoder(bytes.NewReader(data))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return "", err
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			return start.Name.Local, nil
+		}
+	}
+}
+
+// gunzip 按内容头部识别 gzip，使用 urlx 的解压器，解压后受请求的 MaxBodySize 和 MaxDecompressionRatio 限制
+func gunzip(resp *http.Response, r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	if magic, _ := br.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		body, _, err := urlx.DecompressBody(resp, "gzip", br)
+		return body, err
+	}
+	return io.NopCloser(br), nil
+}
@@ -0,0 +1,116 @@
+package feed
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/cnk3x/go/urlx"
+)
+
+func mockHTTPServer(h http.Handler) (string, func()) {
+	listen, _ := net.Listen("tcp", "127.0.0.1:0")
+	s := &http.Server{Handler: h}
+	go func() { _ = s.Serve(listen) }()
+	return "http:" + "//" + listen.Addr().String(), func() { _ = s.Shutdown(context.TODO()) }
+}
+
+func TestWalkSitemap(t *testing.T) {
+	var addr string
+	addr, closer := mockHTTPServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/sitemap.xml":
+			fmt.Fprintf(rw, `<?xml version="1.0" encoding="UTF-8"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+<sitemap><loc>%[1]s/a.xml.gz</loc><lastmod>2024-01-02</lastmod></sitemap>
+<sitemap><loc>%[1]s/b.xml</loc></sitemap>
+<sitemap><loc>%[1]s/sitemap.xml</loc></sitemap>
+</sitemapindex>`, addr)
+		case "/a.xml.gz":
+			var buf bytes.Buffer
+			zw := gzip.NewWriter(&buf)
+			fmt.Fprintf(zw, `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>%s/1</loc><lastmod>2024-01-02T03:04:05+08:00</lastmod><priority>0.5</priority></url></urlset>`, addr)
+			_ = zw.Close()
+			rw.Header().Set(urlx.HeaderContentType, "application/x-gzip")
+			_, _ = rw.Write(buf.Bytes())
+		case "/b.xml":
+			fmt.Fprintf(rw, `<urlset><url><loc>%[1]s/2</loc></url><url><loc>%[1]s/3</loc></url></urlset>`, addr)
+		}
+	}))
+	defer closer()
+
+	var locs []string
+	err := WalkSitemap(context.TODO(), nil, addr+"/sitemap.xml", 2, func(u SitemapURL) error {
+		locs = append(locs, strings.TrimPrefix(u.Loc, addr))
+		if u.Loc == addr+"/1" && (u.LastMod.Hour() != 3 || u.Priority != 0.5) {
+			t.Errorf("bad url: %+v", u)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(locs)
+	if got := strings.Join(locs, ","); got != "/1,/2,/3" {
+		t.Fatalf("locs: %s", got)
+	}
+}
+
+func TestSitemapGzipLimit(t *testing.T) {
+	addr, closer := mockHTTPServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		fmt.Fprint(zw, `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>`)
+		_, _ = zw.Write(bytes.Repeat([]byte("a"), 8<<20))
+		fmt.Fprint(zw, `</loc></url></urlset>`)
+		_ = zw.Close()
+		rw.Header().Set(urlx.HeaderContentType, "application/x-gzip")
+		_, _ = rw.Write(buf.Bytes())
+	}))
+	defer closer()
+
+	var sm Sitemap
+	err := urlx.Default(nil).Url(addr + "/bomb.xml.gz").MaxBodySize(1 << 20).Process(SitemapOf(&sm))
+	if !errors.Is(err, urlx.ErrBodyTooLarge) {
+		t.Fatalf("max size: want ErrBodyTooLarge, got %v", err)
+	}
+	err = urlx.Default(nil).Url(addr + "/bomb.xml.gz").MaxDecompressionRatio(10).Process(SitemapOf(&sm))
+	if !errors.Is(err, urlx.ErrBodyTooLarge) {
+		t.Fatalf("ratio: want ErrBodyTooLarge, got %v", err)
+	}
+}
+
+func TestFeedOf(t *testing.T) {
+	addr, closer := mockHTTPServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rss":
+			fmt.Fprint(rw, `<?xml version="1.0" encoding="UTF-8"?><rss version="2.0"><channel><title>R</title><link>https://r.example/</link>
+<item><title>r1</title><link>https://r.example/1</link><pubDate>Mon, 02 Jan 2006 15:04:05 +0000</pubDate></item></channel></rss>`)
+		case "/atom":
+			fmt.Fprint(rw, `<?xml version="1.0" encoding="utf-8"?><feed xmlns="http://www.w3.org/2005/Atom"><title>A</title><link href="https://a.example/"/>
+<author><name>me</name></author><entry><id>urn:a1</id><title>a1</title><link rel="alternate" href="https://a.example/1"/><updated>2006-01-02T15:04:05Z</updated></entry></feed>`)
+		}
+	}))
+	defer closer()
+
+	var rss, atom Feed
+	if err := urlx.Default(nil).Url(addr + "/rss").Process(FeedOf(&rss)); err != nil {
+		t.Fatal(err)
+	}
+	if err := urlx.Default(nil).Url(addr + "/atom").Process(FeedOf(&atom)); err != nil {
+		t.Fatal(err)
+	}
+	if rss.Title != "R" || len(rss.Items) != 1 || rss.Items[0].ID != "https://r.example/1" || rss.Items[0].Published.Year() != 2006 {
+		t.Fatalf("rss: %+v", rss)
+	}
+	if atom.Link != "https://a.example/" || len(atom.Items) != 1 || atom.Items[0].Author != "me" || atom.Items[0].Published.IsZero() {
+		t.Fatalf("atom: %+v", atom)
+	}
+}
@@ -0,0 +1,146 @@
+package feed
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/cnk3x/go/urlx"
+)
+
+var ErrSitemapDepth = errors.New("sitemap: index nested too deep")
+
+// Sitemap sitemap.xml 或 sitemap 索引，根节点为 urlset 时填充 URLs，为 sitemapindex 时填充 Sitemaps
+type Sitemap struct {
+	XMLName  xml.Name
+	URLs     []SitemapURL `xml:"url"`
+	Sitemaps []SitemapRef `xml:"sitemap"`
+}
+
+// IsIndex 是否为 sitemap 索引
+func (s *Sitemap) IsIndex() bool {
+	return s.XMLName.Local == "sitemapindex"
+}
+
+// SitemapURL sitemap 中的地址
+type SitemapURL struct {
+	Loc        string  `xml:"loc"`
+	LastMod    Time    `xml:"lastmod"`
+	ChangeFreq string  `xml:"changefreq"`
+	Priority   float64 `xml:"priority"`
+}
+
+// SitemapRef sitemap 索引中的子 sitemap
+type SitemapRef struct {
+	Loc     string `xml:"loc"`
+	LastMod Time   `xml:"lastmod"`
+}
+
+// SitemapOf 解析 sitemap.xml 或 sitemap 索引响应，支持 gzip 压缩的 .xml.gz
+func SitemapOf(out *Sitemap) urlx.Process {
+	return decode(out)
+}
+
+// MaxSitemapDepth 递归索引的最大层数
+const MaxSitemapDepth = 5
+
+// WalkSitemap 获取 sitemap，是索引时递归获取子 sitemap，最多 concurrency 个同时进行，
+// fn 依次收到每个地址，不会并发调用，返回错误时停止
+func WalkSitemap(ctx context.Context, newRequest func(ctx context.Context) *urlx.Request, sitemapURL string, concurrency int, fn func(u SitemapURL) error) error {
+	if newRequest == nil {
+		newRequest = urlx.Default
+	}
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	w := &sitemapWalker{newRequest: newRequest, fn: fn, sem: make(chan struct{}, concurrency), seen: map[string]bool{}, cancel: cancel}
+	w.walk(ctx, sitemapURL, 0)
+	w.wg.Wait()
+	return w.err
+}
+
+type sitemapWalker struct {
+	newRequest func(ctx context.Context) *urlx.Request
+	fn         func(u SitemapURL) error
+	sem        chan struct{}
+	wg         sync.WaitGroup
+	cancel     context.CancelFunc
+
+	mu   sync.Mutex
+	seen map[string]bool
+	err  error
+}
+
+func (w *sitemapWalker) walk(ctx context.Context, loc string, depth int) {
+	w.mu.Lock()
+	if w.seen[loc] || w.err != nil {
+		w.mu.Unlock()
+		return
+	}
+	w.seen[loc] = true
+	w.mu.Unlock()
+
+	if depth > MaxSitemapDepth {
+		w.fail(fmt.Errorf("%w: %s", ErrSitemapDepth, loc))
+		return
+	}
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		select {
+		case w.sem <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+		var sitemap Sitemap
+		err := w.newRequest(ctx).Url(loc).Process(status(SitemapOf(&sitemap)))
+		<-w.sem
+		if err != nil {
+			w.fail(fmt.Errorf("sitemap %s: %w", loc, err))
+			return
+		}
+
+		for _, ref := range sitemap.Sitemaps {
+			w.walk(ctx, ref.Loc, depth+1)
+		}
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		for _, u := range sitemap.URLs {
+			if w.err != nil {
+				return
+			}
+			if err := w.fn(u); err != nil {
+				w.err = err
+				w.cancel()
+			}
+		}
+	}()
+}
+
+func (w *sitemapWalker) fail(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err == nil {
+		w.err = err
+		w.cancel()
+	}
+}
+
+// status 非 2xx 的响应返回错误
+func status(next urlx.Process) urlx.Process {
+	return func(resp *http.Response, body io.ReadCloser) error {
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			body.Close()
+			return fmt.Errorf("unexpected status: %s", resp.Status)
+		}
+		return next(resp, body)
+	}
+}
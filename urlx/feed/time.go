@@ -0,0 +1,53 @@
+package feed
+
+import (
+	"encoding/xml"
+	"strings"
+	"time"
+)
+
+// 站点地图、RSS 和 Atom 中常见的时间格式
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	time.RFC3339,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+	"2006-01",
+	"2006",
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"Mon, 02 Jan 2006 15:04 -0700",
+	time.RFC822Z,
+	time.RFC822,
+	"2 Jan 2006 15:04:05 -0700",
+	"02 Jan 2006 15:04:05 MST",
+}
+
+// Time 兼容多种格式的时间，无法解析时为零值
+type Time struct {
+	time.Time
+}
+
+// UnmarshalXML 实现 xml.Unmarshaler
+func (t *Time) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var s string
+	if err := d.DecodeElement(&s, &start); err != nil {
+		return err
+	}
+	t.Time = ParseTime(s)
+	return nil
+}
+
+// ParseTime 依次尝试常见格式解析时间，都失败时返回零值
+func ParseTime(s string) time.Time {
+	s = strings.TrimSpace(s)
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
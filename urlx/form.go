@@ -0,0 +1,17 @@
+package urlx
+
+// Form 可提交的表单，如 htmlquery.Form
+type Form interface {
+	Method() string // 提交方法
+	Action() string // 提交地址
+	Body() Body     // 提交内容，为空则不提交内容
+}
+
+// SubmitForm 以表单的方法、地址和内容提交
+func (c *Request) SubmitForm(form Form) *Request {
+	c.Method(form.Method()).Url(form.Action())
+	if body := form.Body(); body != nil {
+		c.SendBody(body)
+	}
+	return c
+}
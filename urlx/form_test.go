@@ -0,0 +1,41 @@
+package urlx
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type testForm struct {
+	method, action string
+	body           Body
+}
+
+func (f testForm) Method() string { return f.method }
+func (f testForm) Action() string { return f.action }
+func (f testForm) Body() Body     { return f.body }
+
+func TestSubmitForm(t *testing.T) {
+	var got []string
+	addr, closer := mockHTTPServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		got = append(got, r.Method+" "+r.URL.RequestURI()+" "+r.Header.Get(HeaderContentType)+" "+string(data))
+	}))
+	defer closer()
+
+	post := testForm{http.MethodPost, addr + "/login", func() (string, io.Reader, error) {
+		return "application/x-www-form-urlencoded", strings.NewReader("user=a"), nil
+	}}
+	get := testForm{http.MethodGet, addr + "/s?q=x", nil}
+	for _, form := range []testForm{post, get} {
+		if err := Default(nil).SubmitForm(form).Process(nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	eq(t, [][2]any{
+		{len(got), 2},
+		{got[0], "POST /login application/x-www-form-urlencoded user=a"},
+		{got[1], "GET /s?q=x  "},
+	})
+}
@@ -0,0 +1,295 @@
+// Package graphql 基于 urlx 的 GraphQL 客户端，支持变量、操作名、批量查询和自动持久化查询 (APQ)
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/cnk3x/go/urlx"
+	"github.com/goccy/go-json"
+)
+
+var (
+	ErrGraphQL  = errors.New("graphql: error")
+	ErrResponse = errors.New("graphql: invalid response")
+)
+
+// Location 错误在查询中的位置
+type Location struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Error 响应 errors 数组中的一项，errors.Is(err, ErrGraphQL) 为真
+type Error struct {
+	Message    string         `json:"message"`
+	Locations  []Location     `json:"locations,omitempty"`
+	Path       []any          `json:"path,omitempty"` // 字段名为 string，下标为 float64
+	Extensions map[string]any `json:"extensions,omitempty"`
+}
+
+func (e *Error) Error() string {
+	if len(e.Path) == 0 {
+		return "graphql: " + e.Message
+	}
+	return fmt.Sprintf("graphql: %s (path %s)", e.Message, e.PathString())
+}
+
+func (e *Error) Unwrap() error {
+	return ErrGraphQL
+}
+
+// PathString 以 . 连接的路径，如 user.friends.0.name
+func (e *Error) PathString() string {
+	parts := make([]string, len(e.Path))
+	for i, p := range e.Path {
+		parts[i] = fmt.Sprint(p)
+	}
+	return strings.Join(parts, ".")
+}
+
+// Code extensions.code，没有时为空
+func (e *Error) Code() string {
+	code, _ := e.Extensions["code"].(string)
+	return code
+}
+
+// Errors 一个操作返回的全部错误，errors.As 可以取得第一个 *Error
+type Errors []*Error
+
+func (es Errors) Error() string {
+	switch len(es) {
+	case 0:
+		return "graphql: no errors"
+	case 1:
+		return es[0].Error()
+	}
+	return fmt.Sprintf("%s (and %d more errors)", es[0].Error(), len(es)-1)
+}
+
+func (es Errors) Unwrap() error {
+	if len(es) == 0 {
+		return ErrGraphQL
+	}
+	return es[0]
+}
+
+// Operation 一个查询或变更
+type Operation struct {
+	Query         string // 查询文档
+	OperationName string // 文档中有多个操作时指定执行的操作
+	Variables     any    // 变量，以 JSON 编码
+}
+
+// Result 一个操作的响应
+type Result struct {
+	Data       json.RawMessage `json:"data"`
+	Errors     Errors          `json:"errors"`
+	Extensions json.RawMessage `json:"extensions"`
+}
+
+// Err 响应中有错误时返回 Errors
+func (r *Result) Err() error {
+	if len(r.Errors) == 0 {
+		return nil
+	}
+	return r.Errors
+}
+
+// Decode 将 data 解码到 out，data 为空时不做处理
+func (r *Result) Decode(out any) error {
+	if out == nil || len(r.Data) == 0 || string(r.Data) == "null" {
+		return nil
+	}
+	return json.Unmarshal(r.Data, out)
+}
+
+// Client GraphQL 客户端
+type Client struct {
+	endpoint string
+	req      *urlx.Request
+	apq      bool
+}
+
+// New 创建客户端，每次请求以 req 为模板复制，可以在 req 上设置请求头、重试、中间件等，为 nil 时使用 urlx.Default
+func New(endpoint string, req *urlx.Request) *Client {
+	if req == nil {
+		req = urlx.Default(nil)
+	}
+	return &Client{endpoint: endpoint, req: req}
+}
+
+// APQ 启用自动持久化查询，先只发送查询的 sha256，服务端没有缓存时再发送完整查询
+func (c *Client) APQ(enabled bool) *Client {
+	c.apq = enabled
+	return c
+}
+
+// Do 执行操作，将 data 解码到 out，响应中有错误时返回 Errors，同时仍会解码部分数据
+func (c *Client) Do(ctx context.Context, op *Operation, out any) error {
+	result, err := c.Exec(ctx, op)
+	if err != nil {
+		return err
+	}
+	if err = result.Decode(out); err != nil {
+		return err
+	}
+	return result.Err()
+}
+
+// Query 以变量执行查询或变更，同 Do
+func (c *Client) Query(ctx context.Context, query string, variables any, out any) error {
+	return c.Do(ctx, &Operation{Query: query, Variables: variables}, out)
+}
+
+// Exec 执行操作，返回完整的响应，错误只表示请求失败
+func (c *Client) Exec(ctx context.Context, op *Operation) (*Result, error) {
+	results, err := c.exec(ctx, []*Operation{op}, false)
+	if err != nil {
+		return nil, err
+	}
+	return results[0], nil
+}
+
+// Batch 在一个请求中执行多个操作，按顺序返回每个操作的响应，服务端需要支持批量查询
+func (c *Client) Batch(ctx context.Context, ops ...*Operation) ([]*Result, error) {
+	if len(ops) == 0 {
+		return nil, nil
+	}
+	return c.exec(ctx, ops, true)
+}
+
+func (c *Client) exec(ctx context.Context, ops []*Operation, batch bool) ([]*Result, error) {
+	payloads := make([]*payload, len(ops))
+	for i, op := range ops {
+		payloads[i] = newPayload(op, c.apq)
+	}
+	results, err := c.send(ctx, payloads, batch)
+	if err != nil || !c.apq {
+		return results, err
+	}
+
+	// 服务端没有缓存的查询，带上完整查询重新发送
+	var retry []int
+	for i, result := range results {
+		if persistedQueryNotFound(result.Errors) {
+			payloads[i].Query = ops[i].Query
+			retry = append(retry, i)
+		}
+	}
+	if len(retry) == 0 {
+		return results, nil
+	}
+	again := make([]*payload, len(retry))
+	for j, i := range retry {
+		again[j] = payloads[i]
+	}
+	retried, err := c.send(ctx, again, batch)
+	if err != nil {
+		return nil, err
+	}
+	for j, i := range retry {
+		results[i] = retried[j]
+	}
+	return results, nil
+}
+
+// send 发送请求，batch 时以数组发送，响应也应当是同样长度的数组
+func (c *Client) send(ctx context.Context, payloads []*payload, batch bool) (results []*Result, err error) {
+	var body any = payloads[0]
+	if batch {
+		body = payloads
+	}
+
+	err = c.req.WithContext(ctx).
+		Url(c.endpoint).
+		Method(http.MethodPost).
+		HeaderWith(urlx.Accept("application/graphql-response+json, application/json")).
+		SendJSON(body).
+		Process(func(resp *http.Response, body io.ReadCloser) error {
+			defer body.Close()
+			data, err := io.ReadAll(body)
+			if err != nil {
+				return err
+			}
+			data = bytes.TrimSpace(data)
+
+			// 非 2xx 的响应如果带有 data 或 errors 也按正常响应处理
+			if len(data) > 0 && data[0] == '[' && batch {
+				if err = json.Unmarshal(data, &results); err == nil {
+					return nil
+				}
+			} else if len(data) > 0 && data[0] == '{' {
+				var result Result
+				if err = json.Unmarshal(data, &result); err == nil && (result.Data != nil || result.Errors != nil) {
+					results = []*Result{&result}
+					return nil
+				}
+			}
+			if resp.StatusCode < 200 || resp.StatusCode > 299 {
+				return &urlx.StatusError{StatusCode: resp.StatusCode, Status: resp.Status, Body: data}
+			}
+			return fmt.Errorf("%w: %.64q", ErrResponse, data)
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	// 批量请求整体失败时服务端可能只返回一个对象
+	if len(results) == 1 && len(payloads) > 1 && results[0].Data == nil && len(results[0].Errors) > 0 {
+		for len(results) < len(payloads) {
+			results = append(results, results[0])
+		}
+	}
+	if len(results) != len(payloads) {
+		return nil, fmt.Errorf("%w: %d results for %d operations", ErrResponse, len(results), len(payloads))
+	}
+	return results, nil
+}
+
+type payload struct {
+	Query         string      `json:"query,omitempty"`
+	OperationName string      `json:"operationName,omitempty"`
+	Variables     any         `json:"variables,omitempty"`
+	Extensions    *extensions `json:"extensions,omitempty"`
+}
+
+type extensions struct {
+	PersistedQuery *persistedQuery `json:"persistedQuery,omitempty"`
+}
+
+type persistedQuery struct {
+	Version    int    `json:"version"`
+	Sha256Hash string `json:"sha256Hash"`
+}
+
+func newPayload(op *Operation, apq bool) *payload {
+	p := &payload{Query: op.Query, OperationName: op.OperationName, Variables: op.Variables}
+	if apq {
+		p.Query = ""
+		p.Extensions = &extensions{PersistedQuery: &persistedQuery{Version: 1, Sha256Hash: QueryHash(op.Query)}}
+	}
+	return p
+}
+
+// QueryHash 查询的 sha256，用于持久化查询
+func QueryHash(query string) string {
+	sum := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(sum[:])
+}
+
+func persistedQueryNotFound(errs Errors) bool {
+	for _, e := range errs {
+		if e.Message == "PersistedQueryNotFound" || e.Code() == "PERSISTED_QUERY_NOT_FOUND" {
+			return true
+		}
+	}
+	return false
+}
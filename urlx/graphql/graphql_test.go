@@ -0,0 +1,228 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/cnk3x/go/urlx"
+)
+
+type testRequest struct {
+	Query         string `json:"query"`
+	OperationName string `json:"operationName"`
+	Variables     struct {
+		ID string `json:"id"`
+	} `json:"variables"`
+	Extensions struct {
+		PersistedQuery *struct {
+			Version    int    `json:"version"`
+			Sha256Hash string `json:"sha256Hash"`
+		} `json:"persistedQuery"`
+	} `json:"extensions"`
+}
+
+type testServer struct {
+	mu       sync.Mutex
+	cache    map[string]string
+	requests []string
+}
+
+func (s *testServer) resolve(req *testRequest) string {
+	query := req.Query
+	if pq := req.Extensions.PersistedQuery; pq != nil {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if query == "" {
+			if query = s.cache[pq.Sha256Hash]; query == "" {
+				return `{"errors":[{"message":"PersistedQueryNotFound","extensions":{"code":"PERSISTED_QUERY_NOT_FOUND"}}]}`
+			}
+		} else if QueryHash(query) == pq.Sha256Hash {
+			s.cache[pq.Sha256Hash] = query
+		}
+	}
+
+	switch {
+	case strings.Contains(query, "user"):
+		if req.Variables.ID == "0" {
+			return `{"data":{"user":null},"errors":[{"message":"user not found","locations":[{"line":1,"column":20}],"path":["user",0,"name"],"extensions":{"code":"NOT_FOUND"}}]}`
+		}
+		return `{"data":{"user":{"id":"` + req.Variables.ID + `","name":"` + req.OperationName + `"}}}`
+	case strings.Contains(query, "mutation"):
+		return `{"data":{"rename":true}}`
+	}
+	return `{"errors":[{"message":"syntax error"}]}`
+}
+
+func (s *testServer) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	data, _ := io.ReadAll(r.Body)
+	s.mu.Lock()
+	s.requests = append(s.requests, string(data))
+	s.mu.Unlock()
+
+	if r.URL.Path == "/down" {
+		http.Error(rw, "bad gateway", http.StatusBadGateway)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	if bytes.HasPrefix(data, []byte("[")) {
+		var reqs []*testRequest
+		if err := json.Unmarshal(data, &reqs); err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+		out := make([]string, len(reqs))
+		for i, req := range reqs {
+			out[i] = s.resolve(req)
+		}
+		_, _ = rw.Write([]byte("[" + strings.Join(out, ",") + "]"))
+		return
+	}
+
+	var req testRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+	_, _ = rw.Write([]byte(s.resolve(&req)))
+}
+
+type userData struct {
+	User *struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"user"`
+}
+
+type vars struct {
+	ID string `json:"id"`
+}
+
+const userQuery = `query GetUser($id: ID!) { user(id: $id) { id name } }`
+
+func newTestClient() (*testServer, *Client, func()) {
+	s := &testServer{cache: map[string]string{}}
+	ts := httptest.NewServer(s)
+	return s, New(ts.URL, urlx.Default(nil)), ts.Close
+}
+
+func TestQuery(t *testing.T) {
+	_, c, closer := newTestClient()
+	defer closer()
+
+	var out userData
+	err := c.Do(context.Background(), &Operation{Query: userQuery, OperationName: "GetUser", Variables: vars{ID: "7"}}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.User == nil || out.User.ID != "7" || out.User.Name != "GetUser" {
+		t.Fatalf("unexpected data: %+v", out.User)
+	}
+
+	var renamed struct{ Rename bool }
+	if err = c.Query(context.Background(), `mutation { rename(id: 1) }`, nil, &renamed); err != nil || !renamed.Rename {
+		t.Fatalf("mutation: %v %v", renamed, err)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	_, c, closer := newTestClient()
+	defer closer()
+
+	var out userData
+	err := c.Query(context.Background(), userQuery, vars{ID: "0"}, &out)
+	if !errors.Is(err, ErrGraphQL) {
+		t.Fatalf("err = %v, want ErrGraphQL", err)
+	}
+	var ge *Error
+	if !errors.As(err, &ge) {
+		t.Fatalf("err = %T, want *Error", err)
+	}
+	if ge.Code() != "NOT_FOUND" || ge.PathString() != "user.0.name" || len(ge.Locations) != 1 || ge.Locations[0].Column != 20 {
+		t.Fatalf("unexpected error: %+v", ge)
+	}
+	if want := "graphql: user not found (path user.0.name)"; err.Error() != want {
+		t.Fatalf("err = %q, want %q", err, want)
+	}
+
+	c = New(strings.TrimSuffix(c.endpoint, "/")+"/down", nil)
+	var se *urlx.StatusError
+	if err = c.Query(context.Background(), userQuery, nil, nil); !errors.As(err, &se) || se.StatusCode != http.StatusBadGateway {
+		t.Fatalf("err = %v, want StatusError 502", err)
+	}
+}
+
+func TestAPQ(t *testing.T) {
+	s, c, closer := newTestClient()
+	defer closer()
+	c.APQ(true)
+
+	for i := 0; i < 2; i++ {
+		var out userData
+		if err := c.Query(context.Background(), userQuery, vars{ID: "1"}, &out); err != nil {
+			t.Fatal(err)
+		}
+		if out.User == nil || out.User.ID != "1" {
+			t.Fatalf("unexpected data: %+v", out.User)
+		}
+	}
+
+	// 第一次只发送哈希，未命中后发送完整查询，之后只发送哈希
+	if len(s.requests) != 3 {
+		t.Fatalf("requests = %d, want 3", len(s.requests))
+	}
+	for i, withQuery := range []bool{false, true, false} {
+		if got := strings.Contains(s.requests[i], `"query"`); got != withQuery {
+			t.Fatalf("request %d with query = %v: %s", i, got, s.requests[i])
+		}
+		if !strings.Contains(s.requests[i], QueryHash(userQuery)) {
+			t.Fatalf("request %d without hash: %s", i, s.requests[i])
+		}
+	}
+}
+
+func TestBatch(t *testing.T) {
+	s, c, closer := newTestClient()
+	defer closer()
+	c.APQ(true)
+
+	// 预先缓存一个查询，批量中只有未命中的会重发
+	s.cache[QueryHash(userQuery)] = userQuery
+	results, err := c.Batch(context.Background(),
+		&Operation{Query: userQuery, OperationName: "A", Variables: vars{ID: "1"}},
+		&Operation{Query: `mutation { rename(id: 2) }`},
+		&Operation{Query: userQuery, Variables: vars{ID: "0"}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 3 || len(s.requests) != 2 {
+		t.Fatalf("results = %d, requests = %d", len(results), len(s.requests))
+	}
+	if strings.Count(s.requests[1], `"sha256Hash"`) != 1 {
+		t.Fatalf("retried batch: %s", s.requests[1])
+	}
+
+	var a userData
+	if err = results[0].Decode(&a); err != nil || a.User.Name != "A" {
+		t.Fatalf("result 0: %+v %v", a.User, err)
+	}
+	if err = results[1].Err(); err != nil || string(results[1].Data) != `{"rename":true}` {
+		t.Fatalf("result 1: %s %v", results[1].Data, err)
+	}
+	if err = results[2].Err(); !errors.Is(err, ErrGraphQL) {
+		t.Fatalf("result 2: %v", err)
+	}
+	// 没有操作时不发送请求
+	if results, err = c.Batch(context.Background()); err != nil || results != nil || len(s.requests) != 2 {
+		t.Fatalf("empty batch: %v %v, requests = %d", results, err, len(s.requests))
+	}
+}
@@ -0,0 +1,129 @@
+package urlx
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+type hedging struct {
+	after time.Duration
+	max   int
+}
+
+// Hedge 对冲请求，after 时间内没有响应时再发出一个相同的请求，最多同时 max 个，
+// 取最先返回的响应并取消其余的，只应用于幂等的请求
+func (c *Request) Hedge(after time.Duration, max int) *Request {
+	c.hedging = hedging{after: after, max: max}
+	return c
+}
+
+// Hedge 对冲请求的选项
+func Hedge(after time.Duration, max int) Option {
+	return func(c *Request) error {
+		c.Hedge(after, max)
+		return nil
+	}
+}
+
+type hedgeResult struct {
+	resp  *http.Response
+	err   error
+	index int
+}
+
+// hedge 发出请求，设置了对冲时按时机追加请求
+func (c *Request) hedge(ctx context.Context, doer Doer, requestUrl string, attempt int) (*http.Response, error) {
+	if c.hedging.max <= 1 || c.hedging.after <= 0 {
+		req, err := c.build(ctx, requestUrl, attempt)
+		if err != nil {
+			return nil, err
+		}
+		return doer.Do(req)
+	}
+
+	results := make(chan hedgeResult, c.hedging.max)
+	var cancels []context.CancelFunc
+	launch := func() error {
+		ctx, cancel := context.WithCancel(ctx)
+		req, err := c.build(ctx, requestUrl, attempt)
+		if err != nil {
+			cancel()
+			return err
+		}
+		index := len(cancels)
+		cancels = append(cancels, cancel)
+		go func() {
+			resp, err := doer.Do(req)
+			results <- hedgeResult{resp, err, index}
+		}()
+		return nil
+	}
+
+	if err := launch(); err != nil {
+		return nil, err
+	}
+	timer := time.NewTimer(c.hedging.after)
+	defer timer.Stop()
+
+	var last hedgeResult
+	for pending := 1; pending > 0; {
+		select {
+		case r := <-results:
+			pending--
+			if r.err == nil {
+				// 取消其余的请求，丢弃之后到达的响应
+				for i, cancel := range cancels {
+					if i != r.index {
+						cancel()
+					}
+				}
+				go drainHedges(results, pending)
+				r.resp.Body = &cancelBody{ReadCloser: r.resp.Body, cancel: cancels[r.index]}
+				return r.resp, nil
+			}
+			cancels[r.index]()
+			last = r
+			if len(cancels) < c.hedging.max {
+				if err := launch(); err == nil {
+					pending++
+				}
+			}
+		case <-timer.C:
+			if len(cancels) < c.hedging.max {
+				if err := launch(); err != nil {
+					return nil, err
+				}
+				pending++
+				timer.Reset(c.hedging.after)
+			}
+		}
+	}
+
+	for _, cancel := range cancels {
+		cancel()
+	}
+	return nil, last.err
+}
+
+// drainHedges 关闭未被采用的响应
+func drainHedges(results chan hedgeResult, pending int) {
+	for ; pending > 0; pending-- {
+		if r := <-results; r.resp != nil {
+			r.resp.Body.Close()
+		}
+	}
+}
+
+// cancelBody 响应读取完毕关闭时释放请求的 Context
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
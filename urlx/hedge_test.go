@@ -0,0 +1,90 @@
+package urlx
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHedge(t *testing.T) {
+	var hits int32
+	addr, closer := mockHTTPServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&hits, 1) == 1 {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(time.Second):
+			}
+		}
+		_, _ = rw.Write([]byte("fast"))
+	}))
+	defer closer()
+
+	start := time.Now()
+	data, err := Default(nil).Url(addr).Hedge(20*time.Millisecond, 3).Bytes()
+	eq(t, [][2]any{
+		{err, nil},
+		{string(data), "fast"},
+		{time.Since(start) < 500*time.Millisecond, true},
+		{atomic.LoadInt32(&hits), int32(2)},
+	})
+}
+
+func TestFailover(t *testing.T) {
+	bad, closeBad := mockHTTPServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer closeBad()
+	good, closeGood := mockHTTPServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		_, _ = rw.Write([]byte(r.URL.Path))
+	}))
+	defer closeGood()
+
+	listen, _ := net.Listen("tcp", "127.0.0.1:0")
+	dead := "http://" + listen.Addr().String()
+	listen.Close()
+
+	mirrors, err := NewMirrors(MirrorOrdered, dead, bad, good)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := Default(nil).Url("/v1/items").Failover(mirrors).Bytes()
+	eq(t, [][2]any{{err, nil}, {string(data), "/v1/items"}})
+
+	rr, _ := NewMirrors(MirrorRoundRobin, good, bad)
+	eq(t, [][2]any{
+		{rr.targets("http://x/a")[0], good + "/a"},
+		{rr.targets("http://x/a")[0], bad + "/a"},
+	})
+}
+
+func TestFailoverCanceled(t *testing.T) {
+	var goodHits int32
+	slow, closeSlow := mockHTTPServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer closeSlow()
+	good, closeGood := mockHTTPServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&goodHits, 1)
+	}))
+	defer closeGood()
+
+	mirrors, _ := NewMirrors(MirrorOrdered, slow, good)
+	for _, timeout := range []bool{false, true} {
+		ctx, cancel := context.WithCancel(context.Background())
+		if timeout {
+			ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+		} else {
+			time.AfterFunc(20*time.Millisecond, cancel)
+		}
+		err := Default(ctx).Url("/a").Failover(mirrors).Process(nil)
+		cancel()
+		eq(t, [][2]any{{err != nil, true}, {atomic.LoadInt32(&goodHits), int32(0)}})
+	}
+}
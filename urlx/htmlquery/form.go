@@ -0,0 +1,319 @@
+package htmlquery
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	errors "golang.org/x/xerrors"
+)
+
+var ErrFormNotFound = errors.New("form not found")
+
+const (
+	EnctypeURLEncoded = "application/x-www-form-urlencoded"
+	EnctypeMultipart  = "multipart/form-data"
+)
+
+// Form 从页面中解析的表单，可修改字段后提交
+type Form struct {
+	action  *url.URL
+	method  string
+	enctype string
+	fields  []formField
+	buttons []formField
+	clicked []formField
+	files   []formFile
+}
+
+type formField struct {
+	name, value string
+	image       bool // 图片按钮，提交点击坐标 name.x 和 name.y
+}
+
+type formFile struct {
+	name, filename string
+	open           func() (io.ReadCloser, error)
+}
+
+// ParseForm 解析响应中第一个匹配 find 的表单，以响应地址为基础地址
+func ParseForm(out **Form, find string) ProcessType {
+	return func(resp *http.Response, body io.ReadCloser) error {
+		defer body.Close()
+		doc, err := goquery.NewDocumentFromReader(body)
+		if err != nil {
+			return errors.Errorf("read as html: %w", err)
+		}
+		var base *url.URL
+		if resp.Request != nil {
+			base = resp.Request.URL
+		}
+		form, err := NewForm(doc.Find(find), base)
+		if err != nil {
+			return errors.Errorf("%s: %w", find, err)
+		}
+		*out = form
+		return nil
+	}
+}
+
+// NewForm 解析表单，收集默认提交的字段，base 为页面地址，用于解析 action
+func NewForm(sel *goquery.Selection, base *url.URL) (*Form, error) {
+	if sel = sel.Filter("form").First(); sel.Length() == 0 {
+		return nil, ErrFormNotFound
+	}
+
+	if base == nil {
+		base = &url.URL{}
+	}
+	root := sel.Closest("html")
+	if href, ok := root.Find("base[href]").First().Attr("href"); ok {
+		if u, err := base.Parse(strings.TrimSpace(href)); err == nil {
+			base = u
+		}
+	}
+
+	action, err := base.Parse(strings.TrimSpace(sel.AttrOr("action", "")))
+	if err != nil {
+		return nil, errors.Errorf("form action: %w", err)
+	}
+	action.Fragment = ""
+
+	f := &Form{
+		action:  action,
+		method:  strings.ToUpper(strings.TrimSpace(sel.AttrOr("method", http.MethodGet))),
+		enctype: strings.ToLower(strings.TrimSpace(sel.AttrOr("enctype", EnctypeURLEncoded))),
+	}
+	if f.method != http.MethodPost {
+		f.method = http.MethodGet
+	}
+
+	controls := sel.Find("input, select, textarea, button")
+	if id := sel.AttrOr("id", ""); id != "" {
+		controls = controls.AddSelection(root.Find("[form]").FilterFunction(func(_ int, s *goquery.Selection) bool {
+			return s.AttrOr("form", "") == id
+		}))
+	}
+	controls.Each(func(_ int, s *goquery.Selection) { f.collect(s) })
+	return f, nil
+}
+
+// collect 按浏览器的规则收集控件的默认值
+func (f *Form) collect(s *goquery.Selection) {
+	name, ok := s.Attr("name")
+	if !ok || name == "" {
+		return
+	}
+	if _, disabled := s.Attr("disabled"); disabled || s.Closest("fieldset[disabled]").Length() > 0 {
+		return
+	}
+
+	switch goquery.NodeName(s) {
+	case "textarea":
+		f.Add(name, s.Text())
+	case "select":
+		_, multiple := s.Attr("multiple")
+		options := s.Find("option")
+		selected := options.Filter("[selected]")
+		if selected.Length() == 0 && !multiple {
+			selected = options.First()
+		}
+		if !multiple {
+			selected = selected.Last()
+		}
+		selected.Each(func(_ int, o *goquery.Selection) {
+			f.Add(name, o.AttrOr("value", strings.TrimSpace(o.Text())))
+		})
+	case "button":
+		if t := strings.ToLower(s.AttrOr("type", "submit")); t == "submit" {
+			f.buttons = append(f.buttons, formField{name: name, value: s.AttrOr("value", "")})
+		}
+	default:
+		switch strings.ToLower(s.AttrOr("type", "text")) {
+		case "checkbox", "radio":
+			if _, checked := s.Attr("checked"); checked {
+				f.Add(name, s.AttrOr("value", "on"))
+			}
+		case "submit":
+			f.buttons = append(f.buttons, formField{name: name, value: s.AttrOr("value", "")})
+		case "image":
+			f.buttons = append(f.buttons, formField{name: name, image: true})
+		case "button", "reset", "file":
+		default:
+			f.Add(name, s.AttrOr("value", ""))
+		}
+	}
+}
+
+// Method 提交方法
+func (f *Form) Method() string {
+	return f.method
+}
+
+// Enctype 提交编码
+func (f *Form) Enctype() string {
+	return f.enctype
+}
+
+// Action 提交地址，GET 提交时字段编码在地址参数中
+func (f *Form) Action() string {
+	if f.method == http.MethodGet {
+		u := *f.action
+		u.RawQuery = f.Values().Encode()
+		return u.String()
+	}
+	return f.action.String()
+}
+
+// Values 当前所有字段，包含点击的按钮
+func (f *Form) Values() url.Values {
+	values := url.Values{}
+	for _, field := range f.submitted() {
+		values.Add(field.name, field.value)
+	}
+	return values
+}
+
+// Get 获取字段的第一个值
+func (f *Form) Get(name string) string {
+	for _, field := range f.fields {
+		if field.name == name {
+			return field.value
+		}
+	}
+	return ""
+}
+
+// Set 设置字段，替换原有的值并保持字段位置
+func (f *Form) Set(name, value string) *Form {
+	for i, field := range f.fields {
+		if field.name == name {
+			f.fields[i].value = value
+			f.fields = append(f.fields[:i+1], removeField(f.fields[i+1:], name)...)
+			return f
+		}
+	}
+	return f.Add(name, value)
+}
+
+// Add 添加字段值
+func (f *Form) Add(name, value string) *Form {
+	f.fields = append(f.fields, formField{name: name, value: value})
+	return f
+}
+
+// Del 删除字段
+func (f *Form) Del(name string) *Form {
+	f.fields = removeField(f.fields, name)
+	return f
+}
+
+// Click 模拟点击提交按钮，按钮的 name 和 value 一并提交，图片按钮提交坐标 (0, 0)。
+// 再次调用时替换之前点击的按钮，没有该按钮时不提交任何按钮
+func (f *Form) Click(name string) *Form {
+	return f.ClickAt(name, 0, 0)
+}
+
+// ClickAt 模拟在 (x, y) 处点击提交按钮，图片按钮提交 name.x 和 name.y，其他按钮同 Click
+func (f *Form) ClickAt(name string, x, y int) *Form {
+	f.clicked = nil
+	for _, button := range f.buttons {
+		if button.name != name {
+			continue
+		}
+		if button.image {
+			f.clicked = []formField{
+				{name: name + ".x", value: strconv.Itoa(x)},
+				{name: name + ".y", value: strconv.Itoa(y)},
+			}
+		} else {
+			f.clicked = []formField{button}
+		}
+		break
+	}
+	return f
+}
+
+// submitted 提交的字段，点击的按钮在最后
+func (f *Form) submitted() []formField {
+	return append(f.fields[:len(f.fields):len(f.fields)], f.clicked...)
+}
+
+// File 附加文件内容，表单将以 multipart/form-data 提交
+func (f *Form) File(name, filename string, data []byte) *Form {
+	f.files = append(f.files, formFile{name, filename, func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}})
+	return f
+}
+
+// LocalFile 附加本地文件，表单将以 multipart/form-data 提交
+func (f *Form) LocalFile(name, filename string) *Form {
+	f.files = append(f.files, formFile{name, filepath.Base(filename), func() (io.ReadCloser, error) {
+		return os.Open(filename)
+	}})
+	return f
+}
+
+// Body 提交内容，与 urlx.Body 相同，GET 提交时为空
+func (f *Form) Body() func() (contentType string, body io.Reader, err error) {
+	if f.method == http.MethodGet {
+		return nil
+	}
+	if f.enctype == EnctypeMultipart || len(f.files) > 0 {
+		return f.multipart
+	}
+	return func() (contentType string, body io.Reader, err error) {
+		return EnctypeURLEncoded + "; charset=utf-8", strings.NewReader(f.Values().Encode()), nil
+	}
+}
+
+func (f *Form) multipart() (contentType string, body io.Reader, err error) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	for _, field := range f.submitted() {
+		if err = mw.WriteField(field.name, field.value); err != nil {
+			return
+		}
+	}
+	for _, file := range f.files {
+		if err = writeFormFile(mw, file); err != nil {
+			return
+		}
+	}
+	if err = mw.Close(); err != nil {
+		return
+	}
+	return mw.FormDataContentType(), &buf, nil
+}
+
+func writeFormFile(mw *multipart.Writer, file formFile) error {
+	r, err := file.open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	w, err := mw.CreateFormFile(file.name, file.filename)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, r)
+	return err
+}
+
+func removeField(fields []formField, name string) []formField {
+	r := fields[:0]
+	for _, field := range fields {
+		if field.name != name {
+			r = append(r, field)
+		}
+	}
+	return r
+}
@@ -0,0 +1,203 @@
+package htmlquery
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const formPage = `<html><head><base href="/app/"></head><body>
+<form id="login" action="login?next=%2F#top" method="post">
+	<input type="hidden" name="csrf" value="t0k">
+	<input name="user" value="alice">
+	<input type="password" name="pass">
+	<input type="checkbox" name="remember" checked>
+	<input type="checkbox" name="news" value="yes">
+	<input type="radio" name="plan" value="free">
+	<input type="radio" name="plan" value="pro" checked>
+	<select name="lang"><option>en</option><option value="zh">中文</option></select>
+	<select name="tz"><option value="utc">UTC</option><option value="cst" selected>CST</option></select>
+	<select name="tags" multiple><option selected>a</option><option>b</option><option selected>c</option></select>
+	<textarea name="bio">hi</textarea>
+	<input name="off" value="x" disabled>
+	<fieldset disabled><input name="fs" value="x"></fieldset>
+	<input type="file" name="avatar">
+	<input type="reset" name="reset">
+	<input type="submit" name="go" value="Sign in">
+	<button name="alt" value="sso">SSO</button>
+	<input type="image" name="map" src="map.png">
+</form>
+<input name="outside" value="o" form="login">
+<form class="search" action="https://example.com/s"><input name="q" value="go urlx"><input type="submit" value="Search"></form>
+<form class="upload" method="POST" enctype="multipart/form-data"><input type="hidden" name="kind" value="doc"></form>
+</body></html>`
+
+func parseTestForm(t *testing.T, find string) *Form {
+	t.Helper()
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(formPage))
+	if err != nil {
+		t.Fatal(err)
+	}
+	base, _ := url.Parse("https://example.com/user/page?x=1")
+	form, err := NewForm(doc.Find(find), base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return form
+}
+
+func TestNewForm(t *testing.T) {
+	form := parseTestForm(t, "#login")
+	values := form.Values()
+
+	for _, c := range []struct {
+		name string
+		got  any
+		want any
+	}{
+		{"method", form.Method(), http.MethodPost},
+		{"enctype", form.Enctype(), EnctypeURLEncoded},
+		{"action", form.Action(), "https://example.com/app/login?next=%2F"},
+		{"hidden", values.Get("csrf"), "t0k"},
+		{"text", values.Get("user"), "alice"},
+		{"empty password", values["pass"], []string{""}},
+		{"checkbox default value", values.Get("remember"), "on"},
+		{"unchecked checkbox", values["news"], []string(nil)},
+		{"radio", values["plan"], []string{"pro"}},
+		{"select first option", values.Get("lang"), "en"},
+		{"select selected", values.Get("tz"), "cst"},
+		{"select multiple", values["tags"], []string{"a", "c"}},
+		{"textarea", values.Get("bio"), "hi"},
+		{"disabled", values["off"], []string(nil)},
+		{"disabled fieldset", values["fs"], []string(nil)},
+		{"file input", values["avatar"], []string(nil)},
+		{"reset", values["reset"], []string(nil)},
+		{"buttons not clicked", values["go"], []string(nil)},
+		{"form attribute", values.Get("outside"), "o"},
+	} {
+		if !equal(c.got, c.want) {
+			t.Errorf("%s: got %#v, want %#v", c.name, c.got, c.want)
+		}
+	}
+
+	doc, _ := goquery.NewDocumentFromReader(strings.NewReader(formPage))
+	if _, err := NewForm(doc.Find("#missing"), nil); err != ErrFormNotFound {
+		t.Errorf("want ErrFormNotFound, got %v", err)
+	}
+}
+
+func TestFormClick(t *testing.T) {
+	for _, c := range []struct {
+		clicks []string
+		want   string
+	}{
+		{nil, ""},
+		{[]string{"go"}, "go=Sign+in"},
+		{[]string{"go", "go"}, "go=Sign+in"},
+		{[]string{"go", "alt"}, "alt=sso"},
+		{[]string{"map"}, "map.x=0&map.y=0"},
+		{[]string{"go", "missing"}, ""},
+	} {
+		form := parseTestForm(t, "#login")
+		for _, name := range c.clicks {
+			form.Click(name)
+		}
+		values := form.Values()
+		got := url.Values{}
+		for _, key := range []string{"go", "alt", "map", "map.x", "map.y"} {
+			if v, ok := values[key]; ok {
+				got[key] = v
+			}
+		}
+		if got.Encode() != c.want {
+			t.Errorf("click %v: got %q, want %q", c.clicks, got.Encode(), c.want)
+		}
+	}
+
+	form := parseTestForm(t, "#login").ClickAt("map", 12, 34)
+	if v := form.Values(); v.Get("map.x") != "12" || v.Get("map.y") != "34" {
+		t.Errorf("click at: %v", v)
+	}
+}
+
+func TestFormSubmit(t *testing.T) {
+	// GET 提交时字段编码在地址中，替换 action 原有的参数
+	search := parseTestForm(t, ".search").Set("q", "htmlquery")
+	if search.Method() != http.MethodGet || search.Body() != nil {
+		t.Errorf("get form: method %s, has body %v", search.Method(), search.Body() != nil)
+	}
+	if got := search.Action(); got != "https://example.com/s?q=htmlquery" {
+		t.Errorf("get action: %s", got)
+	}
+
+	// urlencoded
+	login := parseTestForm(t, "#login").Set("pass", "secret").Del("bio").Click("go")
+	contentType, body, err := login.Body()()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(body)
+	values, _ := url.ParseQuery(string(data))
+	if !strings.HasPrefix(contentType, EnctypeURLEncoded) || values.Get("pass") != "secret" || values.Has("bio") || values.Get("go") != "Sign in" {
+		t.Errorf("urlencoded: %s %s", contentType, data)
+	}
+
+	// multipart，附加文件时 urlencoded 表单也以 multipart 提交
+	for _, find := range []string{".upload", "#login"} {
+		form := parseTestForm(t, find).File("attachment", "a.txt", []byte("file content"))
+		contentType, body, err = form.Body()()
+		if err != nil {
+			t.Fatal(err)
+		}
+		mediaType, params, _ := mime.ParseMediaType(contentType)
+		if mediaType != EnctypeMultipart {
+			t.Fatalf("%s: content type %s", find, contentType)
+		}
+		parts := map[string]string{}
+		filename := ""
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, _ := io.ReadAll(part)
+			parts[part.FormName()] = string(data)
+			if part.FileName() != "" {
+				filename = part.FileName()
+			}
+		}
+		if parts["attachment"] != "file content" || filename != "a.txt" {
+			t.Errorf("%s: file part %q %q", find, parts["attachment"], filename)
+		}
+		if find == ".upload" && parts["kind"] != "doc" {
+			t.Errorf("%s: fields %v", find, parts)
+		}
+	}
+}
+
+func equal(a, b any) bool {
+	as, ok1 := a.([]string)
+	bs, ok2 := b.([]string)
+	if ok1 && ok2 {
+		if len(as) != len(bs) {
+			return false
+		}
+		for i := range as {
+			if as[i] != bs[i] {
+				return false
+			}
+		}
+		return true
+	}
+	return a == b
+}
@@ -0,0 +1,186 @@
+// Package jsonrpc 基于 urlx 的 JSON-RPC 2.0 客户端，支持通知和批量调用
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/cnk3x/go/urlx"
+	"github.com/goccy/go-json"
+)
+
+var (
+	ErrRPC      = errors.New("jsonrpc: error")
+	ErrResponse = errors.New("jsonrpc: invalid response")
+)
+
+// 规范预定义的错误码
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+// Error 响应中的 error 对象，errors.Is(err, ErrRPC) 为真
+type Error struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("jsonrpc: %s (%d)", e.Message, e.Code)
+}
+
+func (e *Error) Unwrap() error {
+	return ErrRPC
+}
+
+// Call 一次调用，用于批量调用
+type Call struct {
+	Method string // 方法名
+	Params any    // 参数，数组或对象，为 nil 时不发送
+	Result any    // 结果解码到这里，为 nil 时忽略结果
+	Notify bool   // 通知，不带 id，服务端不返回结果
+	Err    error  // 调用的错误，批量调用后设置，服务端返回错误时为 *Error
+}
+
+// Client JSON-RPC 2.0 客户端
+type Client struct {
+	id       uint64 // 原子操作，放在最前保证 32 位平台上 64 位对齐
+	endpoint string
+	req      *urlx.Request
+}
+
+// New 创建客户端，每次请求以 req 为模板复制，可以在 req 上设置请求头、重试、中间件等，为 nil 时使用 urlx.Default
+func New(endpoint string, req *urlx.Request) *Client {
+	if req == nil {
+		req = urlx.Default(nil)
+	}
+	return &Client{endpoint: endpoint, req: req}
+}
+
+// Call 调用方法，将结果解码到 out，服务端返回错误时为 *Error
+func (c *Client) Call(ctx context.Context, method string, params any, out any) error {
+	call := &Call{Method: method, Params: params, Result: out}
+	if err := c.send(ctx, []*Call{call}, false); err != nil {
+		return err
+	}
+	return call.Err
+}
+
+// Notify 发送通知，不等待结果
+func (c *Client) Notify(ctx context.Context, method string, params any) error {
+	return c.send(ctx, []*Call{{Method: method, Params: params, Notify: true}}, false)
+}
+
+// Batch 在一个请求中批量调用，每个调用的错误设置到 Call.Err，返回的错误只表示请求失败
+func (c *Client) Batch(ctx context.Context, calls ...*Call) error {
+	if len(calls) == 0 {
+		return nil
+	}
+	return c.send(ctx, calls, true)
+}
+
+type request struct {
+	Version string  `json:"jsonrpc"`
+	ID      *uint64 `json:"id,omitempty"`
+	Method  string  `json:"method"`
+	Params  any     `json:"params,omitempty"`
+}
+
+type response struct {
+	ID     json.RawMessage `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *Error          `json:"error"`
+}
+
+func (c *Client) send(ctx context.Context, calls []*Call, batch bool) error {
+	reqs := make([]*request, len(calls))
+	pending := map[string]*Call{}
+	for i, call := range calls {
+		reqs[i] = &request{Version: "2.0", Method: call.Method, Params: call.Params}
+		if !call.Notify {
+			id := atomic.AddUint64(&c.id, 1)
+			reqs[i].ID = &id
+			pending[strconv.FormatUint(id, 10)] = call
+		}
+	}
+	var body any = reqs[0]
+	if batch {
+		body = reqs
+	}
+
+	var resps []*response
+	err := c.req.WithContext(ctx).
+		Url(c.endpoint).
+		Method(http.MethodPost).
+		SendJSON(body).
+		Process(func(resp *http.Response, body io.ReadCloser) error {
+			defer body.Close()
+			data, err := io.ReadAll(body)
+			if err != nil {
+				return err
+			}
+			data = bytes.TrimSpace(data)
+			if resp.StatusCode < 200 || resp.StatusCode > 299 {
+				// 部分服务端以非 2xx 返回错误对象
+				var r response
+				if json.Unmarshal(data, &r) != nil || r.Error == nil {
+					return &urlx.StatusError{StatusCode: resp.StatusCode, Status: resp.Status, Body: data}
+				}
+			}
+			switch {
+			case len(pending) == 0:
+				return nil
+			case len(data) > 0 && data[0] == '[':
+				err = json.Unmarshal(data, &resps)
+			default:
+				var r response
+				err = json.Unmarshal(data, &r)
+				resps = []*response{&r}
+			}
+			if err != nil {
+				return fmt.Errorf("%w: %v", ErrResponse, err)
+			}
+			return nil
+		})
+	if err != nil || len(pending) == 0 {
+		return err
+	}
+
+	for _, r := range resps {
+		call, ok := pending[string(bytes.Trim(r.ID, `"`))]
+		if !ok {
+			// id 为 null 的错误属于整个请求，如解析错误
+			if r.Error != nil && (len(r.ID) == 0 || string(r.ID) == "null") {
+				for _, call := range pending {
+					call.Err = r.Error
+				}
+				return nil
+			}
+			continue
+		}
+		delete(pending, string(bytes.Trim(r.ID, `"`)))
+		switch {
+		case r.Error != nil:
+			call.Err = r.Error
+		case call.Result != nil && len(r.Result) > 0:
+			if err = json.Unmarshal(r.Result, call.Result); err != nil {
+				call.Err = fmt.Errorf("%w: %v", ErrResponse, err)
+			}
+		}
+	}
+	for id, call := range pending {
+		call.Err = fmt.Errorf("%w: no response for id %s", ErrResponse, id)
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/cnk3x/go/urlx"
+)
+
+type testRequest struct {
+	Version string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Method  string          `json:"method"`
+	Params  []json.RawMessage
+}
+
+type testResponse struct {
+	Version string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// aria2 风格的接口，第一个参数为 token
+func newTestServer(notified *int32) *httptest.Server {
+	handle := func(req *testRequest) *testResponse {
+		if req.Version != "2.0" {
+			return &testResponse{Version: "2.0", ID: req.ID, Error: &Error{Code: CodeInvalidRequest, Message: "Invalid Request"}}
+		}
+		if len(req.Params) == 0 || string(req.Params[0]) != `"token:secret"` {
+			return &testResponse{Version: "2.0", ID: req.ID, Error: &Error{Code: 1, Message: "Unauthorized", Data: json.RawMessage(`{"hint":"token"}`)}}
+		}
+		resp := &testResponse{Version: "2.0", ID: req.ID}
+		switch req.Method {
+		case "aria2.addUri":
+			var uris []string
+			_ = json.Unmarshal(req.Params[1], &uris)
+			resp.Result = "gid-" + uris[0]
+		case "aria2.getVersion":
+			resp.Result = struct {
+				Version string `json:"version"`
+			}{"1.36.0"}
+		case "aria2.saveSession":
+			atomic.AddInt32(notified, 1)
+		default:
+			resp.Error = &Error{Code: CodeMethodNotFound, Message: "Method not found"}
+		}
+		return resp
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		if r.URL.Path == "/down" {
+			http.Error(rw, "down", http.StatusServiceUnavailable)
+			return
+		}
+		if r.URL.Path == "/parse" {
+			rw.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(rw).Encode(&testResponse{Version: "2.0", ID: json.RawMessage("null"), Error: &Error{Code: CodeParseError, Message: "Parse error"}})
+			return
+		}
+
+		var out []*testResponse
+		batch := bytes.HasPrefix(data, []byte("["))
+		var reqs []*testRequest
+		if batch {
+			_ = json.Unmarshal(data, &reqs)
+		} else {
+			var req testRequest
+			_ = json.Unmarshal(data, &req)
+			reqs = append(reqs, &req)
+		}
+		for _, req := range reqs {
+			resp := handle(req)
+			if len(req.ID) > 0 {
+				out = append(out, resp)
+			}
+		}
+		switch {
+		case len(out) == 0:
+			rw.WriteHeader(http.StatusNoContent)
+		case batch:
+			// 批量结果的顺序不一定与请求相同
+			for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+				out[i], out[j] = out[j], out[i]
+			}
+			_ = json.NewEncoder(rw).Encode(out)
+		default:
+			_ = json.NewEncoder(rw).Encode(out[0])
+		}
+	}))
+}
+
+func TestCall(t *testing.T) {
+	var notified int32
+	ts := newTestServer(&notified)
+	defer ts.Close()
+	c := New(ts.URL, nil)
+
+	var gid string
+	if err := c.Call(context.Background(), "aria2.addUri", []any{"token:secret", []string{"http://a/b"}}, &gid); err != nil {
+		t.Fatal(err)
+	}
+	if gid != "gid-http://a/b" {
+		t.Fatalf("gid = %q", gid)
+	}
+
+	err := c.Call(context.Background(), "aria2.addUri", []any{"token:wrong"}, &gid)
+	var re *Error
+	if !errors.Is(err, ErrRPC) || !errors.As(err, &re) || re.Code != 1 || string(re.Data) != `{"hint":"token"}` {
+		t.Fatalf("err = %v", err)
+	}
+
+	if err = c.Notify(context.Background(), "aria2.saveSession", []any{"token:secret"}); err != nil || atomic.LoadInt32(&notified) != 1 {
+		t.Fatalf("notify: %v, notified = %d", err, notified)
+	}
+
+	var se *urlx.StatusError
+	if err = New(ts.URL+"/down", nil).Call(context.Background(), "x", nil, nil); !errors.As(err, &se) || se.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("err = %v, want StatusError 503", err)
+	}
+	if err = New(ts.URL+"/parse", nil).Call(context.Background(), "x", nil, nil); !errors.As(err, &re) || re.Code != CodeParseError {
+		t.Fatalf("err = %v, want parse error", err)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	var notified int32
+	ts := newTestServer(&notified)
+	defer ts.Close()
+	c := New(ts.URL, nil)
+
+	var gid string
+	var version struct {
+		Version string `json:"version"`
+	}
+	calls := []*Call{
+		{Method: "aria2.addUri", Params: []any{"token:secret", []string{"u1"}}, Result: &gid},
+		{Method: "aria2.getVersion", Params: []any{"token:secret"}, Result: &version},
+		{Method: "aria2.saveSession", Params: []any{"token:secret"}, Notify: true},
+		{Method: "aria2.unknown", Params: []any{"token:secret"}},
+	}
+	if err := c.Batch(context.Background(), calls...); err != nil {
+		t.Fatal(err)
+	}
+	if gid != "gid-u1" || version.Version != "1.36.0" || atomic.LoadInt32(&notified) != 1 {
+		t.Fatalf("gid = %q, version = %q, notified = %d", gid, version.Version, notified)
+	}
+	for i, call := range calls[:3] {
+		if call.Err != nil {
+			t.Fatalf("call %d: %v", i, call.Err)
+		}
+	}
+	var re *Error
+	if !errors.As(calls[3].Err, &re) || re.Code != CodeMethodNotFound {
+		t.Fatalf("call 3: %v", calls[3].Err)
+	}
+
+	// 只有通知时服务端不返回内容
+	if err := c.Batch(context.Background(), &Call{Method: "aria2.saveSession", Params: []any{"token:secret"}, Notify: true}); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,211 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBuckets 耗时直方图的默认分桶，单位秒
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Collector 内存中的指标收集，实现 Recorder，并以 Prometheus 文本格式输出
+type Collector struct {
+	namespace string
+	buckets   []float64
+
+	mu        sync.Mutex
+	requests  map[[3]string]float64 // host, method, status
+	durations map[[2]string]*histogram
+	retries   map[[2]string]float64
+	bytesIn   map[string]float64
+	bytesOut  map[string]float64
+	inFlight  map[string]float64
+}
+
+type histogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// NewCollector 创建收集器，namespace 为指标名前缀，为空时为 urlx，buckets 为空时使用 DefaultBuckets
+func NewCollector(namespace string, buckets ...float64) *Collector {
+	if namespace == "" {
+		namespace = "urlx"
+	}
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	return &Collector{
+		namespace: namespace,
+		buckets:   buckets,
+		requests:  map[[3]string]float64{},
+		durations: map[[2]string]*histogram{},
+		retries:   map[[2]string]float64{},
+		bytesIn:   map[string]float64{},
+		bytesOut:  map[string]float64{},
+		inFlight:  map[string]float64{},
+	}
+}
+
+// InFlight 实现 Recorder
+func (c *Collector) InFlight(host string, delta int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.inFlight[host] += float64(delta)
+}
+
+// Observe 实现 Recorder
+func (c *Collector) Observe(host, method, status string, elapsed time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requests[[3]string{host, method, status}]++
+
+	key := [2]string{host, method}
+	h, ok := c.durations[key]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(c.buckets))}
+		c.durations[key] = h
+	}
+	seconds := elapsed.Seconds()
+	for i, le := range c.buckets {
+		if seconds <= le {
+			h.counts[i]++
+		}
+	}
+	h.sum += seconds
+	h.count++
+}
+
+// Retry 实现 Recorder
+func (c *Collector) Retry(host, method string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.retries[[2]string{host, method}]++
+}
+
+// Bytes 实现 Recorder
+func (c *Collector) Bytes(host string, in, out int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.bytesIn[host] += float64(in)
+	c.bytesOut[host] += float64(out)
+}
+
+// ServeHTTP 以 Prometheus 文本格式输出，可挂载到 httpx.Server 的路由上
+func (c *Collector) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_ = c.Write(rw)
+}
+
+// Write 以 Prometheus 文本格式写出所有指标
+func (c *Collector) Write(out io.Writer) error {
+	w := bufio.NewWriter(out)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ns := c.namespace
+
+	header(w, ns+"_requests_total", "counter", "Outbound HTTP requests by host, method and status class.")
+	for _, key := range sortedKeys3(c.requests) {
+		sample(w, ns+"_requests_total", labels("host", key[0], "method", key[1], "status", key[2]), c.requests[key])
+	}
+
+	header(w, ns+"_request_duration_seconds", "histogram", "Time until response headers are received.")
+	var durationKeys [][2]string
+	for key := range c.durations {
+		durationKeys = append(durationKeys, key)
+	}
+	for _, key := range sortKeys2(durationKeys) {
+		h := c.durations[key]
+		for i, le := range c.buckets {
+			sample(w, ns+"_request_duration_seconds_bucket", labels("host", key[0], "method", key[1], "le", formatFloat(le)), float64(h.counts[i]))
+		}
+		sample(w, ns+"_request_duration_seconds_bucket", labels("host", key[0], "method", key[1], "le", "+Inf"), float64(h.count))
+		sample(w, ns+"_request_duration_seconds_sum", labels("host", key[0], "method", key[1]), h.sum)
+		sample(w, ns+"_request_duration_seconds_count", labels("host", key[0], "method", key[1]), float64(h.count))
+	}
+
+	header(w, ns+"_retries_total", "counter", "Retried outbound HTTP requests.")
+	var retryKeys [][2]string
+	for key := range c.retries {
+		retryKeys = append(retryKeys, key)
+	}
+	for _, key := range sortKeys2(retryKeys) {
+		sample(w, ns+"_retries_total", labels("host", key[0], "method", key[1]), c.retries[key])
+	}
+
+	for _, m := range []struct {
+		name, typ, help string
+		values          map[string]float64
+	}{
+		{ns + "_response_bytes_total", "counter", "Response body bytes received.", c.bytesIn},
+		{ns + "_request_bytes_total", "counter", "Request body bytes sent.", c.bytesOut},
+		{ns + "_requests_in_flight", "gauge", "Outbound HTTP requests waiting for response headers.", c.inFlight},
+	} {
+		header(w, m.name, m.typ, m.help)
+		for _, host := range sortedKeys(m.values) {
+			sample(w, m.name, labels("host", host), m.values[host])
+		}
+	}
+	return w.Flush()
+}
+
+func header(w *bufio.Writer, name, typ, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+func sample(w *bufio.Writer, name, labels string, value float64) {
+	fmt.Fprintf(w, "%s{%s} %s\n", name, labels, formatFloat(value))
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func labels(kv ...string) string {
+	var sb strings.Builder
+	for i := 0; i+1 < len(kv); i += 2 {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(kv[i])
+		sb.WriteString(`="`)
+		sb.WriteString(labelEscaper.Replace(kv[i+1]))
+		sb.WriteByte('"')
+	}
+	return sb.String()
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func sortedKeys(m map[string]float64) (keys []string) {
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return
+}
+
+func sortKeys2(keys [][2]string) [][2]string {
+	sort.Slice(keys, func(i, j int) bool { return keys[i][0]+"\x00"+keys[i][1] < keys[j][0]+"\x00"+keys[j][1] })
+	return keys
+}
+
+func sortedKeys3(m map[[3]string]float64) (keys [][3]string) {
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return strings.Join(keys[i][:], "\x00") < strings.Join(keys[j][:], "\x00")
+	})
+	return
+}
@@ -0,0 +1,93 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cnk3x/go/urlx"
+)
+
+// Recorder 指标记录
+type Recorder interface {
+	InFlight(host string, delta int)                            // 进行中的请求数变化
+	Observe(host, method, status string, elapsed time.Duration) // 请求完成，elapsed 为收到响应头的耗时
+	Retry(host, method string)                                  // 重试
+	Bytes(host string, in, out int64)                           // 收到和发出的字节数
+}
+
+// StatusClass 状态码分类，如 2xx，请求出错时为 error
+func StatusClass(resp *http.Response, err error) string {
+	if err != nil || resp == nil {
+		return "error"
+	}
+	return strconv.Itoa(resp.StatusCode/100) + "xx"
+}
+
+// Middleware 记录请求指标的中间件，响应字节数在 Body 关闭时记录
+func Middleware(r Recorder) urlx.Middleware {
+	return func(next urlx.Doer) urlx.Doer {
+		return urlx.DoerFunc(func(req *http.Request) (*http.Response, error) {
+			host, method := req.URL.Host, req.Method
+			if urlx.Attempt(req) > 0 {
+				r.Retry(host, method)
+			}
+
+			var out *countReader
+			if req.Body != nil && req.Body != http.NoBody {
+				out = &countReader{ReadCloser: req.Body}
+				req.Body = out
+			}
+
+			r.InFlight(host, 1)
+			start := time.Now()
+			resp, err := next.Do(req)
+			r.Observe(host, method, StatusClass(resp, err), time.Since(start))
+			r.InFlight(host, -1)
+
+			var sent int64
+			if out != nil {
+				sent = out.count()
+			}
+			if err != nil {
+				r.Bytes(host, 0, sent)
+				return resp, err
+			}
+			resp.Body = &countReader{ReadCloser: resp.Body, onClose: func(n int64) { r.Bytes(host, n, sent) }}
+			return resp, nil
+		})
+	}
+}
+
+// countReader 统计读取的字节数，关闭时回调一次
+type countReader struct {
+	io.ReadCloser
+	mu      sync.Mutex
+	n       int64
+	onClose func(n int64)
+	once    sync.Once
+}
+
+func (c *countReader) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.mu.Lock()
+	c.n += int64(n)
+	c.mu.Unlock()
+	return n, err
+}
+
+func (c *countReader) Close() error {
+	err := c.ReadCloser.Close()
+	if c.onClose != nil {
+		c.once.Do(func() { c.onClose(c.count()) })
+	}
+	return err
+}
+
+func (c *countReader) count() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.n
+}
@@ -0,0 +1,45 @@
+package metrics
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cnk3x/go/urlx"
+)
+
+func TestCollector(t *testing.T) {
+	listen, _ := net.Listen("tcp", "127.0.0.1:0")
+	s := &http.Server{Handler: http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		_, _ = rw.Write([]byte("hello"))
+	})}
+	go func() { _ = s.Serve(listen) }()
+	defer s.Shutdown(context.TODO())
+	host := listen.Addr().String()
+
+	c := NewCollector("")
+	for i := 0; i < 2; i++ {
+		if _, err := urlx.Default(nil).Url("http://" + host).Method(urlx.MethodPost).SendForm("a=1").Use(Middleware(c)).Bytes(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	c.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	out := rec.Body.String()
+	for _, want := range []string{
+		`urlx_requests_total{host="` + host + `",method="POST",status="2xx"} 2`,
+		`urlx_request_duration_seconds_count{host="` + host + `",method="POST"} 2`,
+		`urlx_response_bytes_total{host="` + host + `"} 10`,
+		`urlx_request_bytes_total{host="` + host + `"} 6`,
+		`urlx_requests_in_flight{host="` + host + `"} 0`,
+		"# TYPE urlx_request_duration_seconds histogram",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %s in\n%s", want, out)
+		}
+	}
+}
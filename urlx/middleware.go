@@ -0,0 +1,82 @@
+package urlx
+
+import (
+	"context"
+	"net/http"
+)
+
+// Doer 执行请求，*http.Client 即是一个 Doer
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// DoerFunc 函数形式的 Doer
+type DoerFunc func(req *http.Request) (*http.Response, error)
+
+// Do 实现 Doer
+func (f DoerFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Middleware 请求中间件，包裹整个请求交换，可以查看最终的 *http.Request、直接返回响应或计时
+type Middleware = func(next Doer) Doer
+
+// Use 增加请求中间件，先加入的在外层，每次重试都会经过
+func (c *Request) Use(mws ...Middleware) *Request {
+	c.middlewares = append(c.middlewares, mws...)
+	return c
+}
+
+// Use 增加请求中间件的选项
+func Use(mws ...Middleware) Option {
+	return func(c *Request) error {
+		c.Use(mws...)
+		return nil
+	}
+}
+
+// Chain 以中间件包裹 Doer，先传入的在外层
+func Chain(doer Doer, mws ...Middleware) Doer {
+	for i := len(mws) - 1; i >= 0; i-- {
+		doer = mws[i](doer)
+	}
+	return doer
+}
+
+// Transport 以中间件包裹 RoundTripper，用于在共享的 http.Client 上安装中间件，
+// 与 Request.Use 不同，重定向的每一跳都会经过，base 为空时使用 http.DefaultTransport
+func Transport(base http.RoundTripper, mws ...Middleware) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return roundTripper{Chain(DoerFunc(base.RoundTrip), mws...)}
+}
+
+// UseTransport 在请求使用的客户端的 Transport 上增加中间件，重定向的每一跳都会经过。
+// 客户端在应用选项前已复制，不影响共用的客户端
+func UseTransport(mws ...Middleware) Option {
+	return func(c *Request) error {
+		c.client.Transport = Transport(c.client.Transport, mws...)
+		return nil
+	}
+}
+
+type roundTripper struct {
+	Doer
+}
+
+func (rt roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return rt.Do(req)
+}
+
+type attemptKey struct{}
+
+// Attempt 请求是第几次尝试，从 0 开始，大于 0 时为重试
+func Attempt(req *http.Request) int {
+	n, _ := req.Context().Value(attemptKey{}).(int)
+	return n
+}
+
+func withAttempt(ctx context.Context, attempt int) context.Context {
+	return context.WithValue(ctx, attemptKey{}, attempt)
+}
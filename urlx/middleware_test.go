@@ -0,0 +1,34 @@
+package urlx
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	var trace []string
+	mark := func(name string) Middleware {
+		return func(next Doer) Doer {
+			return DoerFunc(func(req *http.Request) (*http.Response, error) {
+				trace = append(trace, name+":"+req.Header.Get(HeaderAccept))
+				return next.Do(req)
+			})
+		}
+	}
+	mock := func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusTeapot, Body: io.NopCloser(strings.NewReader("mocked")), Request: req}, nil
+		})
+	}
+
+	data, err := Default(nil).Url("http://urlx.invalid/").HeaderWith(AcceptJSON).Use(mark("a"), mark("b")).Use(mock).Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	eq(t, [][2]any{
+		{string(data), "mocked"},
+		{strings.Join(trace, ","), "a:application/json,b:application/json"},
+	})
+}
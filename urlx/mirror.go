@@ -0,0 +1,91 @@
+package urlx
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+)
+
+// MirrorStrategy 镜像选择策略
+type MirrorStrategy int
+
+const (
+	MirrorOrdered    MirrorStrategy = iota // 总是从第一个开始
+	MirrorRoundRobin                       // 每次请求从下一个开始轮询
+)
+
+// Mirrors 一组可相互替代的基础地址，出现连接错误或 5xx 时依次转移到下一个
+//
+// 请求地址为相对地址时以镜像为基础解析，为绝对地址时替换其协议和主机
+type Mirrors struct {
+	bases    []*url.URL
+	strategy MirrorStrategy
+	next     uint32
+}
+
+// NewMirrors 创建镜像组
+func NewMirrors(strategy MirrorStrategy, bases ...string) (*Mirrors, error) {
+	m := &Mirrors{strategy: strategy}
+	for _, base := range bases {
+		u, err := url.Parse(base)
+		if err != nil {
+			return nil, err
+		}
+		m.bases = append(m.bases, u)
+	}
+	return m, nil
+}
+
+// Failover 在镜像之间故障转移，同一个 Mirrors 可以被多个请求共享，轮询的位置也随之共享
+func (c *Request) Failover(mirrors *Mirrors) *Request {
+	c.mirrors = mirrors
+	return c
+}
+
+// Failover 镜像故障转移的选项
+func Failover(mirrors *Mirrors) Option {
+	return func(c *Request) error {
+		c.Failover(mirrors)
+		return nil
+	}
+}
+
+// targets 本次请求依次尝试的地址
+func (m *Mirrors) targets(requestUrl string) []string {
+	if m == nil || len(m.bases) == 0 {
+		return []string{requestUrl}
+	}
+	ref, err := url.Parse(requestUrl)
+	if err != nil {
+		return []string{requestUrl}
+	}
+
+	start := 0
+	if m.strategy == MirrorRoundRobin {
+		start = int((atomic.AddUint32(&m.next, 1) - 1) % uint32(len(m.bases)))
+	}
+	targets := make([]string, 0, len(m.bases))
+	for i := range m.bases {
+		base := m.bases[(start+i)%len(m.bases)]
+		if ref.IsAbs() {
+			u := *ref
+			u.Scheme, u.Host, u.User = base.Scheme, base.Host, base.User
+			targets = append(targets, u.String())
+		} else {
+			targets = append(targets, base.ResolveReference(ref).String())
+		}
+	}
+	return targets
+}
+
+// shouldFailover 连接错误或 5xx 时转移到下一个镜像，取消的请求不转移
+func shouldFailover(resp *http.Response, err error) bool {
+	if err != nil {
+		var ne net.Error
+		return errors.As(err, &ne) && !errors.Is(err, ErrTooManyRedirects) && !errors.Is(err, context.Canceled)
+	}
+	return resp.StatusCode >= 500
+}
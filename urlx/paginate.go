@@ -0,0 +1,311 @@
+package urlx
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+// Page 一页的响应
+type Page struct {
+	Index    int               // 第几页，从 0 开始
+	Response *http.Response    // 响应，内容已读取到 Body
+	Body     []byte            // 响应内容
+	Items    []json.RawMessage // 本页的条目，内容不是条目数组时为 nil
+}
+
+// PageStrategy 分页策略，由本页的请求和响应得到下一页的请求，返回 nil 时结束
+type PageStrategy = func(req *Request, page *Page) *Request
+
+// Pages 分页迭代器
+//
+//	pages := req.Paginate(urlx.LinkNext()).ItemsAt("data")
+//	for pages.Next() {
+//		var items []Item
+//		if err := pages.DecodeItems(&items); err != nil { ... }
+//	}
+//	if err := pages.Err(); err != nil { ... }
+type Pages struct {
+	next     *Request
+	strategy PageStrategy
+	itemsAt  string
+	maxPages int
+	delay    time.Duration
+
+	page *Page
+	err  error
+	seen map[string]bool
+}
+
+// Paginate 按策略逐页请求，每页照常应用重试、中间件等设置，遇到空页、重复的地址或策略返回 nil 时结束。
+// 页与页之间的速率以 Delay 限制
+func (c *Request) Paginate(strategy PageStrategy) *Pages {
+	return &Pages{next: c, strategy: strategy, seen: map[string]bool{}}
+}
+
+// ItemsAt 条目数组在响应 JSON 中的路径，以 . 分隔，数组下标为数字，为空时响应本身是数组。
+// 路径不存在、为 null 或不是数组时视为空页，翻页结束
+func (p *Pages) ItemsAt(path string) *Pages {
+	p.itemsAt = path
+	return p
+}
+
+// MaxPages 最多请求的页数，0 为不限制
+func (p *Pages) MaxPages(n int) *Pages {
+	p.maxPages = n
+	return p
+}
+
+// Delay 请求每一页之前等待的时间，第一页不等待，等待时请求的 ctx 取消则返回错误
+func (p *Pages) Delay(d time.Duration) *Pages {
+	p.delay = d
+	return p
+}
+
+// Next 请求下一页，没有下一页或出错时返回 false
+func (p *Pages) Next() bool {
+	if p.err != nil || p.next == nil {
+		return false
+	}
+	index := 0
+	if p.page != nil {
+		index = p.page.Index + 1
+	}
+	if p.maxPages > 0 && index >= p.maxPages {
+		return false
+	}
+
+	// 先应用选项，策略对下一页的修改才不会被选项覆盖
+	req, err := p.next.applied()
+	p.next = nil
+	if p.err = err; err != nil {
+		return false
+	}
+	key := req.method + " " + req.RequestURL()
+	if p.seen[key] {
+		return false
+	}
+	p.seen[key] = true
+
+	if p.delay > 0 && index > 0 {
+		select {
+		case <-time.After(p.delay):
+		case <-req.Context().Done():
+			p.err = req.Context().Err()
+			return false
+		}
+	}
+
+	page := &Page{Index: index}
+	p.err = req.Process(func(resp *http.Response, body io.ReadCloser) (err error) {
+		defer body.Close()
+		page.Response = resp
+		if page.Body, err = io.ReadAll(body); err != nil {
+			return
+		}
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return &StatusError{StatusCode: resp.StatusCode, Status: resp.Status, Body: page.Body}
+		}
+		return
+	})
+	if p.err != nil {
+		return false
+	}
+
+	items, err := JSONPath(page.Body, p.itemsAt)
+	if err == nil && bytes.HasPrefix(bytes.TrimSpace(items), []byte("[")) {
+		if p.err = json.Unmarshal(items, &page.Items); p.err != nil {
+			return false
+		}
+		page.Items = append([]json.RawMessage{}, page.Items...)
+	} else if p.itemsAt != "" {
+		// 设置了 ItemsAt 时，路径不存在、为 null 或不是数组的视为空页
+		return false
+	}
+	if len(bytes.TrimSpace(page.Body)) == 0 || (page.Items != nil && len(page.Items) == 0) {
+		return false
+	}
+
+	p.page = page
+	p.next = p.strategy(req, page)
+	return true
+}
+
+// Page 当前页
+func (p *Pages) Page() *Page {
+	return p.page
+}
+
+// Decode 以 JSON 解码当前页
+func (p *Pages) Decode(out any) error {
+	return json.Unmarshal(p.page.Body, out)
+}
+
+// DecodeItems 以 JSON 解码当前页的条目，out 为切片的指针
+func (p *Pages) DecodeItems(out any) error {
+	data, err := json.Marshal(p.page.Items)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+// Err 请求出错时的错误
+func (p *Pages) Err() error {
+	return p.err
+}
+
+// All 请求所有页，将条目解码到 out，out 为切片的指针
+func (p *Pages) All(out any) error {
+	var items []json.RawMessage
+	for p.Next() {
+		items = append(items, p.page.Items...)
+	}
+	if p.err != nil {
+		return p.err
+	}
+	if items == nil {
+		items = []json.RawMessage{}
+	}
+	data, err := json.Marshal(items)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+var linkNextRe = regexp.MustCompile(`<([^>]*)>\s*((?:;\s*[^;,]+)*)`)
+
+// LinkNext 按 RFC 5988 响应头 Link 中 rel="next" 的地址翻页
+func LinkNext() PageStrategy {
+	return func(req *Request, page *Page) *Request {
+		for _, link := range page.Response.Header.Values("Link") {
+			for _, m := range linkNextRe.FindAllStringSubmatch(link, -1) {
+				if !linkRelNext(m[2]) {
+					continue
+				}
+				next, err := page.Response.Request.URL.Parse(m[1])
+				if err != nil {
+					return nil
+				}
+				return req.Clone().Url(next.String()).Query("")
+			}
+		}
+		return nil
+	}
+}
+
+func linkRelNext(params string) bool {
+	for _, param := range strings.Split(params, ";") {
+		k, v, ok := cutString(strings.TrimSpace(param), "=")
+		if !ok || !strings.EqualFold(strings.TrimSpace(k), "rel") {
+			continue
+		}
+		for _, rel := range strings.Fields(strings.Trim(strings.TrimSpace(v), `"`)) {
+			if strings.EqualFold(rel, "next") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CursorParam 从响应 JSON 的 path 处取得游标，作为下一页的 param 参数，游标为空时结束
+func CursorParam(path, param string) PageStrategy {
+	return func(req *Request, page *Page) *Request {
+		raw, err := JSONPath(page.Body, path)
+		if err != nil {
+			return nil
+		}
+		var cursor string
+		if s, err := strconv.Unquote(string(raw)); err == nil {
+			cursor = s
+		} else if raw = bytes.TrimSpace(raw); len(raw) > 0 && (raw[0] == '-' || (raw[0] >= '0' && raw[0] <= '9')) {
+			cursor = string(raw)
+		}
+		if cursor == "" {
+			return nil
+		}
+		return setQueryParam(req, param, cursor)
+	}
+}
+
+// OffsetParam 以 param 参数为偏移量，下一页的偏移量加上本页的条目数，参数不存在时从 0 开始，需要设置 ItemsAt 或响应本身是数组
+func OffsetParam(param string) PageStrategy {
+	return func(req *Request, page *Page) *Request {
+		if page.Items == nil {
+			return nil
+		}
+		offset, _ := strconv.Atoi(queryParam(req, param))
+		return setQueryParam(req, param, strconv.Itoa(offset+len(page.Items)))
+	}
+}
+
+// PageParam 以 param 参数为页码，每页加一，参数不存在时第一页为 start，需要设置 ItemsAt 或响应本身是数组
+func PageParam(param string, start int) PageStrategy {
+	return func(req *Request, page *Page) *Request {
+		if page.Items == nil {
+			return nil
+		}
+		n, err := strconv.Atoi(queryParam(req, param))
+		if err != nil {
+			n = start
+		}
+		return setQueryParam(req, param, strconv.Itoa(n+1))
+	}
+}
+
+func queryParam(req *Request, param string) string {
+	values, _ := url.ParseQuery(req.query)
+	return values.Get(param)
+}
+
+// setQueryParam 复制请求并设置 Query 参数
+func setQueryParam(req *Request, param, value string) *Request {
+	values, _ := url.ParseQuery(req.query)
+	values.Set(param, value)
+	return req.Clone().Query(values.Encode())
+}
+
+// JSONPath 取得 JSON 中 path 处的内容，path 以 . 分隔，数组下标为数字，为空时返回 data 本身
+func JSONPath(data []byte, path string) (json.RawMessage, error) {
+	raw := json.RawMessage(data)
+	if path == "" {
+		return raw, nil
+	}
+	for _, key := range strings.Split(path, ".") {
+		if trimmed := bytes.TrimSpace(raw); len(trimmed) > 0 && trimmed[0] == '[' {
+			i, err := strconv.Atoi(key)
+			if err != nil {
+				return nil, fmt.Errorf("json path %s: %q is not an index", path, key)
+			}
+			var arr []json.RawMessage
+			if err = json.Unmarshal(raw, &arr); err != nil {
+				return nil, err
+			}
+			if i < 0 || i >= len(arr) {
+				return nil, fmt.Errorf("json path %s: index %d out of range", path, i)
+			}
+			raw = arr[i]
+			continue
+		}
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &obj); err != nil {
+			return nil, err
+		}
+		v, ok := obj[key]
+		if !ok {
+			return nil, fmt.Errorf("json path %s: %q not found", path, key)
+		}
+		raw = v
+	}
+	return raw, nil
+}
@@ -0,0 +1,223 @@
+package urlx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPaginateLinkNext(t *testing.T) {
+	addr, closer := mockHTTPServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page < 2 {
+			rw.Header().Add("Link", fmt.Sprintf(`</items?page=%d>; rel="next", </items?page=0>; rel="first"`, page+1))
+		}
+		_, _ = fmt.Fprintf(rw, `[%d,%d]`, page*2, page*2+1)
+	}))
+	defer closer()
+
+	var got []int
+	err := Default(nil).Url(addr + "/items").Query("page=0").Paginate(LinkNext()).All(&got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{0, 1, 2, 3, 4, 5}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestPaginateCursor(t *testing.T) {
+	addr, closer := mockHTTPServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("cursor") {
+		case "":
+			_, _ = rw.Write([]byte(`{"data":[{"id":1},{"id":2}],"meta":{"next":"abc"}}`))
+		case "abc":
+			_, _ = rw.Write([]byte(`{"data":[{"id":3}],"meta":{"next":null}}`))
+		default:
+			rw.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer closer()
+
+	type item struct {
+		ID int `json:"id"`
+	}
+	// 以选项设置的地址和参数不会覆盖下一页的游标
+	withURL := func(r *Request) error {
+		r.Url(addr + "/items").Query("limit=2")
+		return nil
+	}
+	pages := New(nil, withURL).Paginate(CursorParam("meta.next", "cursor")).ItemsAt("data")
+	var got []int
+	for pages.Next() {
+		var items []item
+		if err := pages.DecodeItems(&items); err != nil {
+			t.Fatal(err)
+		}
+		for _, it := range items {
+			got = append(got, it.ID)
+		}
+		if q := pages.Page().Response.Request.URL.Query(); q.Get("limit") != "2" {
+			t.Fatalf("page %d lost query: %v", pages.Page().Index, q)
+		}
+	}
+	if err := pages.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{1, 2, 3}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestPaginateOffset(t *testing.T) {
+	data := []string{"a", "b", "c", "d", "e"}
+	var requests int
+	addr, closer := mockHTTPServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		requests++
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		end := offset + 2
+		if offset > len(data) {
+			offset = len(data)
+		}
+		if end > len(data) {
+			end = len(data)
+		}
+		_, _ = fmt.Fprintf(rw, `{"items":[%s]}`, strings.Join(quoteAll(data[offset:end]), ","))
+	}))
+	defer closer()
+
+	var got []string
+	if err := Default(nil).Url(addr).Query("limit=2").Paginate(OffsetParam("offset")).ItemsAt("items").All(&got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, data) {
+		t.Fatalf("got %v, want %v", got, data)
+	}
+	// 最后一页为空时结束
+	if requests != 4 {
+		t.Fatalf("requests = %d, want 4", requests)
+	}
+}
+
+func TestPaginatePageNumber(t *testing.T) {
+	addr, closer := mockHTTPServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("p"))
+		if page == 3 {
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = fmt.Fprintf(rw, `[%d]`, page)
+	}))
+	defer closer()
+
+	var got []int
+	pages := Default(nil).Url(addr).Paginate(PageParam("p", 1))
+	for pages.Next() {
+		var items []int
+		if err := pages.Decode(&items); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, items...)
+	}
+	var se *StatusError
+	if !errors.As(pages.Err(), &se) || se.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("err = %v, want StatusError 500", pages.Err())
+	}
+	if want := []int{0, 2}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	got = nil
+	if err := Default(nil).Url(addr).Query("p=0").Paginate(PageParam("p", 1)).MaxPages(2).All(&got); err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{0, 1}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	start := time.Now()
+	if err := Default(nil).Url(addr).Query("p=0").Paginate(PageParam("p", 1)).MaxPages(3).Delay(30 * time.Millisecond).All(&got); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+		t.Fatalf("3 pages with delay took %s", elapsed)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	pages = Default(ctx).Url(addr).Query("p=0").Paginate(PageParam("p", 1)).Delay(time.Second)
+	for pages.Next() {
+	}
+	if pages.Err() != context.DeadlineExceeded || pages.Page().Index != 0 {
+		t.Fatalf("err = %v, want context.DeadlineExceeded after first page", pages.Err())
+	}
+}
+
+func TestPaginateLastPageWithoutItems(t *testing.T) {
+	var requests int
+	addr, closer := mockHTTPServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		requests++
+		page, _ := strconv.Atoi(r.URL.Query().Get("p"))
+		switch {
+		case page < 2:
+			_, _ = fmt.Fprintf(rw, `{"data":[%d]}`, page)
+		case r.URL.Path == "/null":
+			_, _ = rw.Write([]byte(`{"data":null}`))
+		case r.URL.Path == "/object":
+			_, _ = rw.Write([]byte(`{"data":{}}`))
+		default:
+			// 最后一页没有条目的字段
+			_, _ = rw.Write([]byte(`{"total":2}`))
+		}
+	}))
+	defer closer()
+
+	for _, path := range []string{"/missing", "/null", "/object"} {
+		requests = 0
+		var got []int
+		if err := Default(nil).Url(addr + path).Query("p=0").Paginate(PageParam("p", 1)).ItemsAt("data").All(&got); err != nil {
+			t.Fatal(err)
+		}
+		if want := []int{0, 1}; !reflect.DeepEqual(got, want) || requests != 3 {
+			t.Fatalf("%s: got %v after %d requests", path, got, requests)
+		}
+	}
+
+	// 没有条目时 PageParam 不再翻页
+	requests = 0
+	pages := Default(nil).Url(addr + "/missing").Query("p=0").Paginate(PageParam("p", 1))
+	for pages.Next() {
+	}
+	if pages.Err() != nil || requests != 1 {
+		t.Fatalf("err %v after %d requests", pages.Err(), requests)
+	}
+}
+
+func TestJSONPath(t *testing.T) {
+	data := []byte(`{"a":{"b":[{"c":1},{"c":"x"}]}}`)
+	for path, want := range map[string]string{"a.b.0.c": `1`, "a.b.1.c": `"x"`, "": string(data)} {
+		got, err := JSONPath(data, path)
+		if err != nil || string(got) != want {
+			t.Fatalf("%q: got %s, %v, want %s", path, got, err, want)
+		}
+	}
+	for _, path := range []string{"a.x", "a.b.2", "a.b.c"} {
+		if _, err := JSONPath(data, path); err == nil {
+			t.Fatalf("%q: want error", path)
+		}
+	}
+}
+
+func quoteAll(ss []string) []string {
+	out := make([]string, len(ss))
+	for i, s := range ss {
+		out[i] = strconv.Quote(s)
+	}
+	return out
+}
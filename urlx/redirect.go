@@ -0,0 +1,116 @@
+package urlx
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+var ErrTooManyRedirects = errors.New("redirect: too many redirects")
+
+// RedirectHeaderPolicy 重定向时敏感请求头(Authorization、Cookie 等)的处理策略
+type RedirectHeaderPolicy int
+
+const (
+	RedirectHeadersDefault  RedirectHeaderPolicy = iota // 标准库默认，跳转到非同域或子域时丢弃
+	RedirectHeadersSameHost                             // 仅主机完全相同时保留
+	RedirectHeadersAlways                               // 始终保留请求设置的值，包括跨主机跳转，Cookie 容器中的 Cookie 仍按主机发送
+	RedirectHeadersNever                                // 任何跳转都丢弃
+)
+
+var sensitiveHeaders = []string{"Authorization", "Www-Authenticate", "Cookie", "Cookie2", "Proxy-Authorization"}
+
+type redirectPolicy struct {
+	set      bool
+	max      int
+	noFollow bool
+	headers  RedirectHeaderPolicy
+}
+
+// RedirectHop 重定向链中的一跳
+type RedirectHop struct {
+	Method string
+	URL    string
+	Status int
+}
+
+// MaxRedirects 最大重定向次数，超过时返回 ErrTooManyRedirects，n <= 0 时不跟随重定向
+func (c *Request) MaxRedirects(n int) *Request {
+	c.redirect.set = true
+	c.redirect.max = n
+	c.redirect.noFollow = n <= 0
+	return c
+}
+
+// NoRedirect 不跟随重定向，直接返回 3xx 响应
+func (c *Request) NoRedirect() *Request {
+	c.redirect.set = true
+	c.redirect.noFollow = true
+	return c
+}
+
+// RedirectHeaders 设置重定向时敏感请求头的处理策略
+func (c *Request) RedirectHeaders(policy RedirectHeaderPolicy) *Request {
+	c.redirect.set = true
+	c.redirect.headers = policy
+	return c
+}
+
+// RedirectChain 响应经过的重定向链，从第一个请求到最终响应
+func RedirectChain(resp *http.Response) (hops []RedirectHop) {
+	for r := resp; r != nil && r.Request != nil; r = r.Request.Response {
+		hops = append([]RedirectHop{{Method: r.Request.Method, URL: r.Request.URL.String(), Status: r.StatusCode}}, hops...)
+	}
+	return
+}
+
+// client 应用重定向策略，复制一份客户端，不修改共享的客户端，headers 为请求设置的请求头
+func (p redirectPolicy) client(client *http.Client, headers http.Header) *http.Client {
+	if !p.set {
+		return client
+	}
+
+	nc := *client
+	nc.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if p.noFollow {
+			return http.ErrUseLastResponse
+		}
+		max := p.max
+		if max <= 0 {
+			max = 10
+		}
+		if len(via) >= max {
+			return fmt.Errorf("%w: stopped after %d redirects", ErrTooManyRedirects, len(via))
+		}
+
+		prev := via[len(via)-1]
+		switch p.headers {
+		case RedirectHeadersSameHost:
+			if !strings.EqualFold(req.URL.Host, prev.URL.Host) {
+				delHeaders(req.Header, sensitiveHeaders)
+			}
+		case RedirectHeadersAlways:
+			// 只恢复请求设置的值，已发出的请求头中还有 Cookie 容器为前一个主机添加的 Cookie
+			for _, key := range sensitiveHeaders {
+				if values, ok := headers[key]; ok && req.Header.Get(key) == "" {
+					req.Header[key] = append([]string(nil), values...)
+				}
+			}
+		case RedirectHeadersNever:
+			delHeaders(req.Header, sensitiveHeaders)
+		}
+
+		if client.CheckRedirect != nil {
+			return client.CheckRedirect(req, via)
+		}
+		return nil
+	}
+	return &nc
+}
+
+func delHeaders(headers http.Header, keys []string) {
+	for _, key := range keys {
+		headers.Del(key)
+	}
+}
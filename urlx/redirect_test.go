@@ -0,0 +1,90 @@
+package urlx
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestRedirect(t *testing.T) {
+	addr, closer := mockHTTPServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		n, _ := strconv.Atoi(r.URL.Query().Get("n"))
+		if n > 0 {
+			http.Redirect(rw, r, "/?n="+strconv.Itoa(n-1), http.StatusFound)
+			return
+		}
+		_, _ = rw.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	defer closer()
+
+	var hops []RedirectHop
+	var data []byte
+	err := Default(nil).Url(addr + "/?n=2").HeaderWith(HeaderSet("Authorization", "token")).
+		Process(func(resp *http.Response, body io.ReadCloser) (err error) {
+			hops = RedirectChain(resp)
+			data, err = io.ReadAll(body)
+			return
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	eq(t, [][2]any{
+		{len(hops), 3},
+		{hops[0].URL, addr + "/?n=2"},
+		{hops[0].Status, http.StatusFound},
+		{hops[2].URL, addr + "/?n=0"},
+		{hops[2].Status, http.StatusOK},
+		{string(data), "token"},
+	})
+
+	var status int
+	err = Default(nil).Url(addr + "/?n=2").NoRedirect().Process(func(resp *http.Response, body io.ReadCloser) error {
+		status = resp.StatusCode
+		return nil
+	})
+	eq(t, [][2]any{{err, nil}, {status, http.StatusFound}})
+
+	err = Default(nil).Url(addr + "/?n=3").MaxRedirects(2).TryAt(0).Process(nil)
+	eq(t, [][2]any{{errors.Is(err, ErrTooManyRedirects), true}})
+
+	data, err = Default(nil).Url(addr + "/?n=1").HeaderWith(HeaderSet("Authorization", "token")).RedirectHeaders(RedirectHeadersNever).Bytes()
+	eq(t, [][2]any{{err, nil}, {string(data), ""}})
+}
+
+func TestRedirectHeadersAlways(t *testing.T) {
+	// 127.0.0.1 和 localhost 是两个主机，Cookie 不区分端口
+	var hostB string
+	addrA, closeA := mockHTTPServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" {
+			http.SetCookie(rw, &http.Cookie{Name: "a", Value: "1", Path: "/"})
+			return
+		}
+		http.Redirect(rw, r, hostB+"/echo", http.StatusFound)
+	}))
+	defer closeA()
+	addrB, closeB := mockHTTPServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" {
+			http.SetCookie(rw, &http.Cookie{Name: "b", Value: "2", Path: "/"})
+			return
+		}
+		_, _ = rw.Write([]byte(r.Header.Get("Authorization") + "|" + r.Header.Get("Cookie")))
+	}))
+	defer closeB()
+	hostB = strings.Replace(addrB, "127.0.0.1", "localhost", 1)
+
+	req := Default(nil).With(CookieEnabled())
+	for _, u := range []string{addrA + "/login", hostB + "/login"} {
+		if _, err := req.Clone().Url(u).Bytes(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := req.Clone().Url(addrA + "/go").HeaderWith(HeaderSet("Authorization", "token")).RedirectHeaders(RedirectHeadersAlways).Bytes()
+	eq(t, [][2]any{{err, nil}, {string(data), "token|b=2"}})
+
+	data, err = req.Clone().Url(addrA + "/go").HeaderWith(HeaderSet("Cookie", "c=3")).RedirectHeaders(RedirectHeadersAlways).Bytes()
+	eq(t, [][2]any{{err, nil}, {string(data), "|c=3; b=2"}})
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 )
 
@@ -32,11 +33,17 @@ type Request struct {
 	headers   []HeaderOption // 请求头处理
 
 	// response fields
-	beforeMw []ProcessMw // 中间件
+	beforeMw    []ProcessMw  // 中间件
+	middlewares []Middleware // 请求中间件
 
 	// client fields
 	tryTimes []time.Duration // 重试时间和时机
 	client   *http.Client    // client
+	redirect redirectPolicy  // 重定向策略
+	hedging  hedging         // 对冲请求
+	mirrors  *Mirrors        // 镜像故障转移
+	limits   bodyLimits      // 响应内容限制
+	timeouts timeouts        // 超时设置
 }
 
 /*请求公共设置*/
@@ -58,3 +65,35 @@ func (c *Request) Query(query string) *Request {
 	c.query = query
 	return c
 }
+
+/*读取请求设置*/
+
+// Context 请求的 Context
+func (c *Request) Context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
+// RequestURL 请求的完整地址，包含 Query 参数
+func (c *Request) RequestURL() string {
+	requestUrl := c.url
+	if c.query != "" {
+		if strings.Contains(requestUrl, "?") {
+			requestUrl += "&" + c.query
+		} else {
+			requestUrl += "?" + c.query
+		}
+	}
+	return requestUrl
+}
+
+// Header 依次应用请求头处理后得到的请求头
+func (c *Request) Header() http.Header {
+	headers := http.Header{}
+	for _, headerOption := range c.headers {
+		headerOption(headers)
+	}
+	return headers
+}
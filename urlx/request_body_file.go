@@ -0,0 +1,55 @@
+package urlx
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// SendFile 提交文件内容，设置 Content-Length，类型按扩展名识别，无法识别时按内容识别，每次尝试重新打开文件
+func (c *Request) SendFile(path string) *Request {
+	return c.SendBody(func() (contentType string, body io.Reader, err error) {
+		f, err := os.Open(path)
+		if err != nil {
+			return
+		}
+		stat, err := f.Stat()
+		if err == nil && stat.IsDir() {
+			err = fmt.Errorf("%s is a directory", path)
+		}
+		if err != nil {
+			f.Close()
+			return
+		}
+
+		if contentType = mime.TypeByExtension(filepath.Ext(path)); contentType == "" {
+			head := make([]byte, 512)
+			n, _ := f.ReadAt(head, 0)
+			contentType = http.DetectContentType(head[:n])
+		}
+		return contentType, &sizedBody{io.NewSectionReader(f, 0, stat.Size()), f}, nil
+	})
+}
+
+// sizedBody 已知长度的请求内容，构造请求时据此设置 Content-Length
+type sizedBody struct {
+	*io.SectionReader
+	io.Closer
+}
+
+// setContentLength 为 SendFile 的请求内容设置 Content-Length
+//
+// 只认 *sizedBody，其他实现 Size() 的类型 (如已读过一部分的 *io.SectionReader) 的 Size 不一定是剩余的字节数
+func setContentLength(req *http.Request, body io.Reader) {
+	if sized, ok := body.(*sizedBody); ok && req.ContentLength == 0 {
+		if req.ContentLength = sized.Size(); req.ContentLength == 0 {
+			if closer, ok := body.(io.Closer); ok {
+				closer.Close()
+			}
+			req.Body, req.GetBody = http.NoBody, nil
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package urlx
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSendFile(t *testing.T) {
+	var flaky int
+	addr, closer := mockHTTPServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		if r.URL.Path == "/flaky" {
+			// 第一次尝试读取后断开，重试时重新打开文件
+			flaky++
+			if flaky == 1 {
+				panic(http.ErrAbortHandler)
+			}
+		}
+		_, _ = rw.Write([]byte(r.Header.Get("Content-Type") + "|" + strconv.FormatInt(r.ContentLength, 10) + "|" +
+			r.Header.Get("Transfer-Encoding") + "|" + string(data)))
+	}))
+	defer closer()
+
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		fn := filepath.Join(dir, name)
+		if err := os.WriteFile(fn, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return fn
+	}
+
+	for _, tc := range [][2]string{
+		{write("a.txt", "hello"), "text/plain; charset=utf-8|5||hello"},
+		{write("page", "<html><body>x</body></html>"), "text/html; charset=utf-8|27||<html><body>x</body></html>"},
+		{write("empty.json", ""), "application/json|0||"},
+	} {
+		data, err := Default(nil).Url(addr).Method(http.MethodPut).SendFile(tc[0]).Bytes()
+		eq(t, [][2]any{{err, nil}, {string(data), tc[1]}})
+	}
+
+	data, err := Default(nil).Url(addr + "/flaky").Method(http.MethodPut).TryAt(time.Millisecond).SendFile(filepath.Join(dir, "a.txt")).Bytes()
+	eq(t, [][2]any{{err, nil}, {string(data), "text/plain; charset=utf-8|5||hello"}, {flaky, 2}})
+
+	// 其他实现 Size() 的请求内容不据此设置 Content-Length
+	data, err = Default(nil).Url(addr).Method(http.MethodPut).SendBody(func() (string, io.Reader, error) {
+		r := io.NewSectionReader(strings.NewReader("abcdef"), 0, 6)
+		_, err := r.Read(make([]byte, 2))
+		return "text/plain", r, err
+	}).Bytes()
+	eq(t, [][2]any{{err, nil}, {string(data), "text/plain|-1||cdef"}})
+
+	if _, err = Default(nil).Url(addr).SendFile(filepath.Join(dir, "missing")).Bytes(); !os.IsNotExist(err) {
+		t.Fatalf("err = %v, want not exist", err)
+	}
+	if _, err = Default(nil).Url(addr).SendFile(dir).Bytes(); err == nil {
+		t.Fatal("want error for directory")
+	}
+}
@@ -0,0 +1,154 @@
+package urlx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"sync"
+	"time"
+)
+
+// Clone 复制请求，请求头处理、选项、中间件等列表各自独立，客户端共用
+func (c *Request) Clone() *Request {
+	r := *c
+	r.options = append([]Option(nil), c.options...)
+	r.headers = append([]HeaderOption(nil), c.headers...)
+	r.beforeMw = append([]ProcessMw(nil), c.beforeMw...)
+	r.middlewares = append([]Middleware(nil), c.middlewares...)
+	r.tryTimes = append([]time.Duration(nil), c.tryTimes...)
+	return &r
+}
+
+// WithContext 以 ctx 复制请求，用于以同一个请求为模板发出多个请求
+func (c *Request) WithContext(ctx context.Context) *Request {
+	r := c.Clone()
+	r.ctx = ctx
+	return r
+}
+
+// applied 应用选项后的副本，不修改请求本身
+//
+// 列表截断容量，选项追加时不会写入共用的底层数组；客户端浅拷贝，选项修改客户端时不影响其他请求
+func (c *Request) applied() (*Request, error) {
+	r := *c
+	r.options = nil
+	r.headers = c.headers[:len(c.headers):len(c.headers)]
+	r.beforeMw = c.beforeMw[:len(c.beforeMw):len(c.beforeMw)]
+	r.middlewares = c.middlewares[:len(c.middlewares):len(c.middlewares)]
+	r.tryTimes = c.tryTimes[:len(c.tryTimes):len(c.tryTimes)]
+	if c.client == nil {
+		r.client = &http.Client{}
+	} else {
+		client := *c.client
+		r.client = &client
+	}
+	for _, apply := range c.options {
+		if err := apply(&r); err != nil {
+			return &r, err
+		}
+	}
+	if r.ctx == nil {
+		r.ctx = context.Background()
+	}
+	if r.method == "" {
+		r.method = http.MethodGet
+	}
+	return &r, nil
+}
+
+// Build 应用选项、Query 参数、请求内容和请求头，构造将要发送的请求，不会发送
+//
+// 一次性的 io.Reader 请求内容被构造后不能再发送
+func (c *Request) Build() (*http.Request, error) {
+	r, err := c.applied()
+	if err != nil {
+		return nil, err
+	}
+	if r.buildBody == nil {
+		r.buildBody = func() (contentType string, body io.Reader, err error) { return "", nil, nil }
+	}
+	req, err := r.build(r.ctx, r.RequestURL(), 0)
+	var be *buildError
+	if errors.As(err, &be) {
+		return nil, be.err
+	}
+	return req, err
+}
+
+// Dump 构造请求并输出发送时的报文，不会发送
+func (c *Request) Dump() ([]byte, error) {
+	req, err := c.Build()
+	if err != nil {
+		return nil, err
+	}
+	return httputil.DumpRequestOut(req, true)
+}
+
+var dumpMu sync.Mutex
+
+// DumpRequest 以 "> " 开头逐行输出发出的请求报文，body 为是否包含请求内容
+func DumpRequest(w io.Writer, body bool) Middleware {
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			data, err := httputil.DumpRequestOut(req, body)
+			if err != nil {
+				return nil, err
+			}
+			writeDump(w, fmt.Sprintf("第%d次尝试", Attempt(req)+1), "> ", data)
+			return next.Do(req)
+		})
+	}
+}
+
+// DumpResponse 以 "< " 开头逐行输出收到的响应报文，body 为是否包含响应内容，读取内容时受 MaxBodySize 限制
+func DumpResponse(w io.Writer, body bool) Middleware {
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			resp, err := next.Do(req)
+			if err != nil {
+				writeDump(w, req.Method+" "+req.URL.String(), "< ", []byte(err.Error()))
+				return resp, err
+			}
+			if body {
+				limited, err := bodyLimitsOf(resp).raw(resp)
+				if err != nil {
+					resp.Body.Close()
+					return nil, err
+				}
+				resp.Body = struct {
+					io.Reader
+					io.Closer
+				}{limited, resp.Body}
+			}
+			data, err := httputil.DumpResponse(resp, body)
+			if err != nil {
+				resp.Body.Close()
+				return nil, err
+			}
+			writeDump(w, req.Method+" "+req.URL.String(), "< ", data)
+			return resp, nil
+		})
+	}
+}
+
+// Debug 输出请求和响应的报文，包含内容
+func Debug(w io.Writer) Option {
+	return Use(DumpRequest(w, true), DumpResponse(w, true))
+}
+
+func writeDump(w io.Writer, title, prefix string, data []byte) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "* %s\n", title)
+	for _, line := range bytes.Split(bytes.TrimRight(data, "\r\n"), []byte("\n")) {
+		buf.WriteString(prefix)
+		buf.Write(bytes.TrimRight(line, "\r"))
+		buf.WriteByte('\n')
+	}
+	dumpMu.Lock()
+	defer dumpMu.Unlock()
+	_, _ = w.Write(buf.Bytes())
+}
@@ -0,0 +1,68 @@
+package urlx
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestBuild(t *testing.T) {
+	var optionRuns int
+	req := New(nil).Url("http://example.com/a").Query("b=1").Method(MethodPut).
+		HeaderWith(HeaderSet("X-Test", "1")).
+		SendForm(map[string]string{"k": "v"}).
+		With(func(c *Request) error {
+			optionRuns++
+			c.HeaderWith(HeaderSet("X-Option", "yes"))
+			return nil
+		})
+
+	r, err := req.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(r.Body)
+	eq(t, [][2]any{
+		{r.Method, "PUT"},
+		{r.URL.String(), "http://example.com/a?b=1"},
+		{r.Header.Get("X-Test"), "1"},
+		{r.Header.Get("X-Option"), "yes"},
+		{r.Header.Get(HeaderContentType), "application/x-www-form-urlencoded; charset=utf-8"},
+		{string(body), "k=v"},
+		{optionRuns, 1},
+		{len(req.headers), 1},
+	})
+
+	dump, err := req.Dump()
+	eq(t, [][2]any{{err, nil}, {strings.HasPrefix(string(dump), "PUT /a?b=1 HTTP/1.1\r\nHost: example.com\r\n"), true}, {strings.HasSuffix(string(dump), "\r\n\r\nk=v"), true}})
+
+	errBody := errors.New("body")
+	_, err = New(nil).Url("http://x").SendBody(func() (string, io.Reader, error) { return "", nil, errBody }).Build()
+	eq(t, [][2]any{{err, errBody}})
+}
+
+func TestDebug(t *testing.T) {
+	addr, closer := mockHTTPServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("X-Reply", "ok")
+		_, _ = rw.Write([]byte("pong"))
+	}))
+	defer closer()
+
+	var buf bytes.Buffer
+	data, err := Default(nil).Url(addr + "/ping").With(Debug(&buf)).SendBody(func() (string, io.Reader, error) {
+		return "text/plain", strings.NewReader("ping"), nil
+	}).Bytes()
+	out := buf.String()
+	eq(t, [][2]any{
+		{err, nil},
+		{string(data), "pong"},
+		{strings.Contains(out, "* 第1次尝试\n> GET /ping HTTP/1.1\n"), true},
+		{strings.Contains(out, "\n> ping\n"), true},
+		{strings.Contains(out, "< HTTP/1.1 200 OK\n"), true},
+		{strings.Contains(out, "< X-Reply: ok\n"), true},
+		{strings.HasSuffix(out, "< pong\n"), true},
+	})
+}
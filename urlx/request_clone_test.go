@@ -0,0 +1,64 @@
+package urlx
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestCloneTemplate(t *testing.T) {
+	addr, closer := mockHTTPServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		_, _ = rw.Write([]byte(r.URL.Path + "|" + r.Header.Get("X-Option") + "|" + r.Header.Get("X-Clone")))
+	}))
+	defer closer()
+
+	tpl := Default(nil).Url(addr+"/tpl").With(
+		func(c *Request) error {
+			c.HeaderWith(HeaderSet("X-Option", "yes"))
+			return nil
+		},
+		CookieEnabled(),
+	)
+
+	// 同一个请求重复处理，选项不会累积
+	for i := 0; i < 3; i++ {
+		data, err := tpl.Bytes()
+		eq(t, [][2]any{{err, nil}, {string(data), "/tpl|yes|"}})
+	}
+	eq(t, [][2]any{{len(tpl.headers), 2}, {tpl.client == nil, true}})
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 40)
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			path := "/" + strconv.Itoa(i)
+			r := tpl.WithContext(context.Background()).Url(addr + path).HeaderWith(HeaderSet("X-Clone", path))
+			data, err := r.Bytes()
+			if err == nil && string(data) != path+"|yes|"+path {
+				err = strconv.ErrSyntax
+			}
+			errs <- err
+		}(i)
+		go func() {
+			defer wg.Done()
+			_, err := tpl.Bytes()
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	// 并发时多拨号的连接没有发出请求，服务端关闭时会等待它们
+	http.DefaultTransport.(*http.Transport).CloseIdleConnections()
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	clone := tpl.Clone().Method(MethodPost).Use(DumpRequest(nil, false))
+	eq(t, [][2]any{{tpl.method, ""}, {len(tpl.middlewares), 0}, {len(clone.middlewares), 1}, {len(clone.options), len(tpl.options)}})
+}
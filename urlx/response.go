@@ -8,7 +8,6 @@ import (
 	"log"
 	"net"
 	"net/http"
-	"strings"
 	"time"
 
 	"github.com/goccy/go-json"
@@ -32,65 +31,55 @@ func (c *Request) ProcessWith(mws ...ProcessMw) *Request {
 	return c
 }
 
-// Process 处理响应
+// Process 处理响应，选项应用在副本上，同一个请求可以重复或并发处理
 func (c *Request) Process(process Process) error {
-	if c.client == nil {
-		c.client = &http.Client{}
-	}
-
-	for _, apply := range c.options {
-		if err := apply(c); err != nil {
-			return err
-		}
+	r, err := c.applied()
+	if err != nil {
+		return err
 	}
+	return r.process(process)
+}
 
-	if c.ctx == nil {
-		c.ctx = context.Background()
-	}
+func (c *Request) process(process Process) error {
+	requestUrl := c.RequestURL()
 
-	if c.method == "" {
-		c.method = http.MethodGet
+	if c.buildBody == nil {
+		c.buildBody = func() (contentType string, body io.Reader, err error) { return "", nil, nil }
 	}
 
-	requestUrl := c.url
-	if c.query != "" {
-		if strings.Contains(requestUrl, "?") {
-			requestUrl += "&" + c.query
-		} else {
-			requestUrl += "?" + c.query
-		}
+	ctx, cancel := context.WithCancel(c.ctx)
+	defer cancel()
+	var overall *deadline
+	if c.timeouts.overall > 0 {
+		overall = newDeadline(c.timeouts.overall, cancel)
+		defer overall.stop()
 	}
 
-	if c.buildBody == nil {
-		c.buildBody = func() (contentType string, body io.Reader, err error) { return "", nil, nil }
+	middlewares := c.middlewares
+	if c.timeouts.attempt > 0 {
+		middlewares = append(middlewares[:len(middlewares):len(middlewares)], attemptTimeout(c.timeouts.attempt))
 	}
+	doer := Chain(c.redirect.client(c.client, c.Header()), middlewares...)
 
 	var resp *http.Response
 	for i := 0; i < len(c.tryTimes)+1; i++ {
-		contentType, body, err := c.buildBody()
-		if err != nil {
-			return err
-		}
-
-		req, err := http.NewRequestWithContext(c.ctx, c.method, requestUrl, body)
-		if err != nil {
-			return err
-		}
-
-		if contentType != "" {
-			req.Header.Set(HeaderContentType, contentType)
-		}
-
-		for _, headerOption := range c.headers {
-			headerOption(req.Header)
-		}
-
-		if resp, err = c.client.Do(req); err != nil {
+		var err error
+		if resp, err = c.attempt(ctx, doer, requestUrl, i); err != nil {
+			var be *buildError
+			if errors.As(err, &be) {
+				return be.err
+			}
+			if overall.expired() {
+				return c.overallTimeout(err)
+			}
 			var ne net.Error
-			if i < len(c.tryTimes) && errors.As(err, &ne) {
+			if i < len(c.tryTimes) && errors.As(err, &ne) && !errors.Is(err, ErrTooManyRedirects) {
 				log.Printf("第%d次出错: %v, %s后重试", i+1, err, c.tryTimes[i])
 				select {
-				case <-c.ctx.Done():
+				case <-ctx.Done():
+					if overall.expired() {
+						return c.overallTimeout(err)
+					}
 					return err
 				case <-time.After(c.tryTimes[i]):
 					continue
@@ -110,13 +99,85 @@ func (c *Request) Process(process Process) error {
 		process = before(process)
 	}
 
-	return process(resp, io.NopCloser(resp.Body))
+	body, err := c.limits.raw(resp)
+	if err != nil {
+		return err
+	}
+	if c.timeouts.idle > 0 {
+		body = newIdleReader(body, c.timeouts.idle, cancel)
+	}
+	if err = process(resp, io.NopCloser(body)); err != nil && overall.expired() {
+		var te *TimeoutError
+		if !errors.As(err, &te) {
+			return c.overallTimeout(err)
+		}
+	}
+	return err
+}
+
+func (c *Request) overallTimeout(err error) error {
+	return &TimeoutError{Kind: TimeoutOverall, Duration: c.timeouts.overall, Err: err}
+}
+
+// buildError 构造请求时的错误，直接返回，不重试
+type buildError struct {
+	err error
+}
+
+func (e *buildError) Error() string {
+	return e.err.Error()
+}
+
+// build 构造第 attempt 次尝试的请求
+func (c *Request) build(ctx context.Context, requestUrl string, attempt int) (*http.Request, error) {
+	contentType, body, err := c.buildBody()
+	if err != nil {
+		return nil, &buildError{err}
+	}
+
+	req, err := http.NewRequestWithContext(withBodyLimits(withAttempt(ctx, attempt), c.limits), c.method, requestUrl, body)
+	if err != nil {
+		if closer, ok := body.(io.Closer); ok {
+			closer.Close()
+		}
+		return nil, &buildError{err}
+	}
+	setContentLength(req, body)
+
+	if contentType != "" {
+		req.Header.Set(HeaderContentType, contentType)
+	}
+
+	for _, headerOption := range c.headers {
+		headerOption(req.Header)
+	}
+	return req, nil
+}
+
+// attempt 一次尝试，设置了镜像时依次故障转移，设置了对冲时并发发出
+func (c *Request) attempt(ctx context.Context, doer Doer, requestUrl string, attempt int) (resp *http.Response, err error) {
+	targets := c.mirrors.targets(requestUrl)
+	for j, target := range targets {
+		resp, err = c.hedge(ctx, doer, target, attempt)
+		// 调用方已取消或超时的请求不再转移
+		if j == len(targets)-1 || ctx.Err() != nil || !shouldFailover(resp, err) {
+			return
+		}
+		if err != nil {
+			log.Printf("%s 出错: %v, 转移到下一个镜像", target, err)
+		} else {
+			log.Printf("%s 返回 %s, 转移到下一个镜像", target, resp.Status)
+			resp.Body.Close()
+		}
+	}
+	return
 }
 
 // Bytes 处理响应字节
 func (c *Request) Bytes() (data []byte, err error) {
 	err = c.Process(func(resp *http.Response, body io.ReadCloser) (err error) {
-		data, err = io.ReadAll(resp.Body)
+		defer body.Close()
+		data, err = io.ReadAll(body)
 		return
 	})
 	return
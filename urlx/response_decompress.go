@@ -17,35 +17,59 @@ func DecompressionBody(next Process) Process {
 	return func(resp *http.Response, body io.ReadCloser) (err error) {
 		defer body.Close()
 		contentEncoding := resp.Header.Get(HeaderContentEncoding)
-		if contentEncoding != "" {
-			decoded := true
-			switch contentEncoding {
-			case "br":
-				body = io.NopCloser(brotli.NewReader(body))
-			case "deflate":
-				body = flate.NewReader(body)
-			case "gzip":
-				body, err = gzip.NewReader(body)
-			case "s2":
-				body = io.NopCloser(s2.NewReader(body))
-			case "snappy":
-				body = io.NopCloser(snappy.NewReader(body))
-			case "zstd":
-				b, er := zstd.NewReader(body)
-				if er != nil {
-					return er
-				}
-				body = b.IOReadCloser()
-			default:
-				decoded = false
-			}
-			if err != nil {
-				return
-			}
-			if decoded {
-				resp.Header.Del(HeaderContentEncoding)
-			}
+		if contentEncoding == "" {
+			return next(resp, body)
 		}
-		return next(resp, io.NopCloser(body))
+
+		decompressed, decoded, err := DecompressBody(resp, contentEncoding, body)
+		if err != nil {
+			return
+		}
+		defer decompressed.Close()
+		if !decoded {
+			return next(resp, body)
+		}
+		resp.Header.Del(HeaderContentEncoding)
+		return next(resp, io.NopCloser(decompressed))
+	}
+}
+
+// DecompressBody 按编码解压响应内容，解压后受请求的 MaxBodySize 和 MaxDecompressionRatio 限制，
+// 用于 Content-Encoding 之外的压缩内容，如 .gz 文件
+func DecompressBody(resp *http.Response, encoding string, body io.Reader) (io.ReadCloser, bool, error) {
+	raw := &limitedReader{r: body}
+	decompressed, decoded, err := Decompress(encoding, raw)
+	if err != nil || !decoded {
+		return decompressed, decoded, err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{bodyLimitsOf(resp).decompressed(decompressed, raw), decompressed}, true, nil
+}
+
+// Decompress 按编码解压，不支持的编码原样返回，decoded 为 false
+func Decompress(encoding string, r io.Reader) (body io.ReadCloser, decoded bool, err error) {
+	decoded = true
+	switch encoding {
+	case "br":
+		body = io.NopCloser(brotli.NewReader(r))
+	case "deflate":
+		body = flate.NewReader(r)
+	case "gzip":
+		body, err = gzip.NewReader(r)
+	case "s2":
+		body = io.NopCloser(s2.NewReader(r))
+	case "snappy":
+		body = io.NopCloser(snappy.NewReader(r))
+	case "zstd":
+		b, er := zstd.NewReader(r)
+		if er != nil {
+			return nil, false, er
+		}
+		body = b.IOReadCloser()
+	default:
+		body, decoded = io.NopCloser(r), false
 	}
+	return
 }
@@ -0,0 +1,124 @@
+package urlx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+var ErrBodyTooLarge = errors.New("body: too large")
+
+// BodyTooLargeError 响应内容超过限制，errors.Is(err, ErrBodyTooLarge) 为真
+type BodyTooLargeError struct {
+	Limit int64   // 超过的字节数限制，按比例限制时为 0
+	Ratio float64 // 超过的解压比例限制，按大小限制时为 0
+}
+
+func (e *BodyTooLargeError) Error() string {
+	if e.Ratio > 0 {
+		return fmt.Sprintf("%s: decompression ratio exceeds %g", ErrBodyTooLarge, e.Ratio)
+	}
+	return fmt.Sprintf("%s: exceeds %d bytes", ErrBodyTooLarge, e.Limit)
+}
+
+func (e *BodyTooLargeError) Unwrap() error {
+	return ErrBodyTooLarge
+}
+
+// ratioFloor 解压后不超过此大小时不检查解压比例，避免小响应误判
+const ratioFloor = 1 << 20
+
+type bodyLimits struct {
+	maxSize  int64
+	maxRatio float64
+}
+
+// MaxBodySize 响应内容的最大字节数，原始内容和解压后的内容都受限，超过时返回 ErrBodyTooLarge，0 为不限制
+func (c *Request) MaxBodySize(n int64) *Request {
+	c.limits.maxSize = n
+	return c
+}
+
+// MaxBodySize 响应内容最大字节数的选项
+func MaxBodySize(n int64) Option {
+	return func(c *Request) error {
+		c.MaxBodySize(n)
+		return nil
+	}
+}
+
+// MaxDecompressionRatio 解压后与解压前的最大比例，防止压缩炸弹，超过时返回 ErrBodyTooLarge，0 为不限制
+func (c *Request) MaxDecompressionRatio(ratio float64) *Request {
+	c.limits.maxRatio = ratio
+	return c
+}
+
+// MaxDecompressionRatio 最大解压比例的选项
+func MaxDecompressionRatio(ratio float64) Option {
+	return func(c *Request) error {
+		c.MaxDecompressionRatio(ratio)
+		return nil
+	}
+}
+
+type bodyLimitsKey struct{}
+
+func withBodyLimits(ctx context.Context, limits bodyLimits) context.Context {
+	if limits == (bodyLimits{}) {
+		return ctx
+	}
+	return context.WithValue(ctx, bodyLimitsKey{}, limits)
+}
+
+func bodyLimitsOf(resp *http.Response) bodyLimits {
+	if resp == nil || resp.Request == nil {
+		return bodyLimits{}
+	}
+	limits, _ := resp.Request.Context().Value(bodyLimitsKey{}).(bodyLimits)
+	return limits
+}
+
+// raw 限制原始响应内容
+func (l bodyLimits) raw(resp *http.Response) (io.Reader, error) {
+	if l.maxSize <= 0 {
+		return resp.Body, nil
+	}
+	if resp.ContentLength > l.maxSize {
+		return nil, &BodyTooLargeError{Limit: l.maxSize}
+	}
+	return &limitedReader{r: resp.Body, max: l.maxSize}, nil
+}
+
+// decompressed 限制解压后的内容，raw 为解压前的读取计数
+func (l bodyLimits) decompressed(r io.Reader, raw *limitedReader) io.Reader {
+	if l.maxSize <= 0 && l.maxRatio <= 0 {
+		return r
+	}
+	return &limitedReader{r: r, max: l.maxSize, ratio: l.maxRatio, raw: raw}
+}
+
+// limitedReader 超过限制时返回 *BodyTooLargeError
+type limitedReader struct {
+	r     io.Reader
+	n     int64
+	max   int64
+	ratio float64
+	raw   *limitedReader
+}
+
+func (l *limitedReader) Read(p []byte) (n int, err error) {
+	if l.max > 0 && int64(len(p)) > l.max-l.n+1 {
+		p = p[:l.max-l.n+1]
+	}
+	n, err = l.r.Read(p)
+	l.n += int64(n)
+	if l.max > 0 && l.n > l.max {
+		return n, &BodyTooLargeError{Limit: l.max}
+	}
+	if l.ratio > 0 && l.raw != nil && l.n > ratioFloor && float64(l.n) > l.ratio*float64(l.raw.n) {
+		return n, &BodyTooLargeError{Ratio: l.ratio}
+	}
+	return
+}
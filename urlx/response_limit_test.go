@@ -0,0 +1,76 @@
+package urlx
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestMaxBodySize(t *testing.T) {
+	payload := bytes.Repeat([]byte("a"), 4096)
+	addr, closer := mockHTTPServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/chunked" {
+			rw.(http.Flusher).Flush()
+		} else {
+			rw.Header().Set("Content-Length", strconv.Itoa(len(payload)))
+		}
+		_, _ = rw.Write(payload)
+	}))
+	defer closer()
+
+	data, err := Default(nil).Url(addr).MaxBodySize(4096).Bytes()
+	eq(t, [][2]any{{err, nil}, {len(data), 4096}})
+
+	_, err = Default(nil).Url(addr).MaxBodySize(1024).Bytes()
+	var tooLarge *BodyTooLargeError
+	eq(t, [][2]any{{errors.Is(err, ErrBodyTooLarge), true}, {errors.As(err, &tooLarge), true}, {tooLarge.Limit, int64(1024)}})
+
+	_, err = Default(nil).Url(addr + "/chunked").With(MaxBodySize(1024)).Bytes()
+	eq(t, [][2]any{{errors.Is(err, ErrBodyTooLarge), true}})
+
+	// 输出响应报文时同样受限
+	var dump bytes.Buffer
+	for _, path := range []string{"", "/chunked"} {
+		_, err = Default(nil).Url(addr + path).MaxBodySize(1024).With(Debug(&dump)).Bytes()
+		eq(t, [][2]any{{errors.Is(err, ErrBodyTooLarge), true}, {bytes.Contains(dump.Bytes(), payload[:1025]), false}})
+	}
+
+	fn := filepath.Join(t.TempDir(), "out.bin")
+	err = Default(nil).Url(addr + "/chunked").MaxBodySize(1024).Download(fn)
+	_, statErr := os.Stat(fn + ".urlx_dl_temp")
+	eq(t, [][2]any{{errors.Is(err, ErrBodyTooLarge), true}, {os.IsNotExist(statErr), true}})
+}
+
+func TestMaxDecompressionRatio(t *testing.T) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, _ = zw.Write(make([]byte, 8<<20))
+	_ = zw.Close()
+	bomb := buf.Bytes()
+
+	addr, closer := mockHTTPServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set(HeaderContentEncoding, "gzip")
+		_, _ = rw.Write(bomb)
+	}))
+	defer closer()
+
+	newReq := func() *Request {
+		return Default(nil).Url(addr).HeaderWith(AcceptEncoding("gzip")).ProcessWith(DecompressionBody)
+	}
+
+	data, err := newReq().Bytes()
+	eq(t, [][2]any{{err, nil}, {len(data), 8 << 20}})
+
+	var out map[string]any
+	_, err = newReq().MaxDecompressionRatio(100).JSON(&out)
+	var tooLarge *BodyTooLargeError
+	eq(t, [][2]any{{errors.As(err, &tooLarge), true}, {tooLarge.Ratio, float64(100)}})
+
+	_, err = newReq().MaxBodySize(1 << 20).Bytes()
+	eq(t, [][2]any{{errors.Is(err, ErrBodyTooLarge), true}})
+}
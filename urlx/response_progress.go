@@ -22,9 +22,13 @@ func (c *Request) Download(fn string) (err error) {
 				return err
 			}
 			defer f.Close()
-			_, err = io.Copy(f, resp.Body)
+			_, err = io.Copy(f, body)
 			return err
 		}()
+		if err != nil {
+			_ = os.Remove(tempFn)
+			return
+		}
 
 		return os.Rename(tempFn, fn)
 	})
@@ -0,0 +1,152 @@
+package robots
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/cnk3x/go/urlx"
+)
+
+// ErrDisallowed robots.txt 不允许抓取
+var ErrDisallowed = errors.New("robots: disallowed")
+
+// DisallowedError robots.txt 不允许抓取，errors.Is(err, ErrDisallowed) 成立
+type DisallowedError struct {
+	URL   string
+	Agent string
+}
+
+func (e *DisallowedError) Error() string {
+	return fmt.Sprintf("robots: %s disallowed for %q", e.URL, e.Agent)
+}
+
+func (e *DisallowedError) Unwrap() error {
+	return ErrDisallowed
+}
+
+// MaxSize robots.txt 读取的最大字节数，超出部分忽略
+const MaxSize = 500 << 10
+
+// Cache 按主机获取并缓存 robots.txt
+type Cache struct {
+	newRequest func(ctx context.Context) *urlx.Request
+	ttl        time.Duration
+	errTTL     time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*entry
+}
+
+type entry struct {
+	once    sync.Once
+	robots  *Robots
+	err     error
+	expires time.Time
+}
+
+// NewCache 创建缓存，newRequest 为获取 robots.txt 使用的请求，为空时使用 urlx.Default，ttl 为 0 时缓存一天
+func NewCache(newRequest func(ctx context.Context) *urlx.Request, ttl time.Duration) *Cache {
+	if newRequest == nil {
+		newRequest = urlx.Default
+	}
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+	return &Cache{newRequest: newRequest, ttl: ttl, errTTL: time.Minute, entries: map[string]*entry{}}
+}
+
+// Get 获取地址所在主机的 robots.txt
+//
+// 4xx 视为允许全部，5xx 视为禁止全部并短暂缓存，网络错误返回错误且不缓存
+func (c *Cache) Get(ctx context.Context, u *url.URL) (*Robots, error) {
+	key := u.Scheme + "://" + u.Host
+	now := time.Now()
+
+	c.mu.Lock()
+	e, ok := c.entries[key]
+	if !ok || now.After(e.expires) {
+		e = &entry{expires: now.Add(c.ttl)}
+		c.entries[key] = e
+	}
+	c.mu.Unlock()
+
+	e.once.Do(func() {
+		e.robots, e.err = c.fetch(ctx, key+"/robots.txt")
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		switch {
+		case e.err != nil:
+			if c.entries[key] == e {
+				delete(c.entries, key)
+			}
+		case e.robots == nil:
+			e.robots, e.expires = DisallowAll(), time.Now().Add(c.errTTL)
+		}
+	})
+	return e.robots, e.err
+}
+
+// Check 检查 agent 是否允许抓取地址，不允许时返回 *DisallowedError
+func (c *Cache) Check(ctx context.Context, u *url.URL, agent string) error {
+	robots, err := c.Get(ctx, u)
+	if err != nil {
+		return err
+	}
+	if !robots.Allowed(agent, u.RequestURI()) {
+		return &DisallowedError{URL: u.String(), Agent: agent}
+	}
+	return nil
+}
+
+// Enforce 请求选项，每次发出请求之前按最终请求头中的 User-Agent 检查 robots.txt，重定向的每一跳都会检查
+func (c *Cache) Enforce() urlx.Option {
+	check := urlx.UseTransport(func(next urlx.Doer) urlx.Doer {
+		return urlx.DoerFunc(func(req *http.Request) (*http.Response, error) {
+			if err := c.Check(req.Context(), req.URL, req.Header.Get(urlx.HeaderUserAgent)); err != nil {
+				return nil, err
+			}
+			return next.Do(req)
+		})
+	})
+	// 客户端以 *url.Error 包裹 Transport 的错误，取出 *DisallowedError，不作为网络错误重试
+	unwrap := urlx.Use(func(next urlx.Doer) urlx.Doer {
+		return urlx.DoerFunc(func(req *http.Request) (*http.Response, error) {
+			resp, err := next.Do(req)
+			var de *DisallowedError
+			if errors.As(err, &de) {
+				return nil, de
+			}
+			return resp, err
+		})
+	})
+	return func(r *urlx.Request) error {
+		if err := unwrap(r); err != nil {
+			return err
+		}
+		return check(r)
+	}
+}
+
+// fetch 获取 robots.txt，5xx 时返回 nil
+func (c *Cache) fetch(ctx context.Context, robotsURL string) (robots *Robots, err error) {
+	err = c.newRequest(ctx).Url(robotsURL).Process(func(resp *http.Response, body io.ReadCloser) error {
+		defer body.Close()
+		switch {
+		case resp.StatusCode >= 200 && resp.StatusCode < 300:
+			robots, err = Parse(io.LimitReader(body, MaxSize))
+			return err
+		case resp.StatusCode >= 500:
+			return nil
+		default:
+			robots = AllowAll()
+			return nil
+		}
+	})
+	return
+}
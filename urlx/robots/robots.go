@@ -0,0 +1,163 @@
+package robots
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Robots 解析后的 robots.txt
+type Robots struct {
+	Groups   []*Group // 规则组
+	Sitemaps []string // Sitemap 地址
+}
+
+// Group 一组 User-agent 共用的规则
+type Group struct {
+	Agents     []string      // User-agent，小写
+	Rules      []Rule        // Allow 和 Disallow
+	CrawlDelay time.Duration // Crawl-delay
+}
+
+// Rule 一条 Allow 或 Disallow
+type Rule struct {
+	Allow   bool
+	Pattern string // 路径模式，支持 * 通配和 $ 结尾
+}
+
+// AllowAll 允许全部，robots.txt 不存在时使用
+func AllowAll() *Robots {
+	return &Robots{Groups: []*Group{{Agents: []string{"*"}}}}
+}
+
+// DisallowAll 禁止全部，robots.txt 暂时无法访问时使用
+func DisallowAll() *Robots {
+	return &Robots{Groups: []*Group{{Agents: []string{"*"}, Rules: []Rule{{Pattern: "/"}}}}}
+}
+
+// Parse 解析 robots.txt，无法识别的行忽略
+func Parse(r io.Reader) (*Robots, error) {
+	robots := &Robots{}
+	var cur *Group
+	inAgents := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		i := strings.IndexByte(line, ':')
+		if i < 0 {
+			continue
+		}
+		key, value := strings.ToLower(strings.TrimSpace(line[:i])), strings.TrimSpace(line[i+1:])
+
+		switch key {
+		case "user-agent":
+			if !inAgents {
+				cur = &Group{}
+				robots.Groups = append(robots.Groups, cur)
+			}
+			cur.Agents = append(cur.Agents, strings.ToLower(value))
+			inAgents = true
+		case "allow", "disallow":
+			inAgents = false
+			if cur != nil && value != "" {
+				cur.Rules = append(cur.Rules, Rule{Allow: key == "allow", Pattern: value})
+			}
+		case "crawl-delay":
+			inAgents = false
+			if cur != nil {
+				if f, err := strconv.ParseFloat(value, 64); err == nil && f > 0 {
+					cur.CrawlDelay = time.Duration(f * float64(time.Second))
+				}
+			}
+		case "sitemap":
+			if value != "" {
+				robots.Sitemaps = append(robots.Sitemaps, value)
+			}
+		}
+	}
+	return robots, scanner.Err()
+}
+
+// Group 选取与 agent 匹配的规则组，agent 可以是完整的 User-Agent 字符串，
+// 取包含的最长产品标识对应的组，没有则使用 *，都没有时允许全部
+func (r *Robots) Group(agent string) *Group {
+	agent = strings.ToLower(agent)
+	var best *Group
+	bestLen := -1
+	for _, g := range r.Groups {
+		for _, a := range g.Agents {
+			if a == "*" {
+				if bestLen < 0 {
+					best, bestLen = g, 0
+				}
+			} else if strings.Contains(agent, a) && len(a) > bestLen {
+				best, bestLen = g, len(a)
+			}
+		}
+	}
+	if best == nil {
+		return &Group{}
+	}
+	return best
+}
+
+// Allowed agent 是否允许抓取 path，path 包含 Query 参数
+func (r *Robots) Allowed(agent, path string) bool {
+	return r.Group(agent).Allowed(path)
+}
+
+// CrawlDelay agent 的抓取间隔
+func (r *Robots) CrawlDelay(agent string) time.Duration {
+	return r.Group(agent).CrawlDelay
+}
+
+// Allowed 路径是否允许抓取，最长匹配优先，长度相同时 Allow 优先
+func (g *Group) Allowed(path string) bool {
+	if path == "" {
+		path = "/"
+	}
+	if path == "/robots.txt" {
+		return true
+	}
+	allow, matched := true, -1
+	for _, rule := range g.Rules {
+		if Match(rule.Pattern, path) {
+			if l := len(rule.Pattern); l > matched || (l == matched && rule.Allow) {
+				allow, matched = rule.Allow, l
+			}
+		}
+	}
+	return allow
+}
+
+// Match 路径是否匹配模式，支持 * 通配和 $ 结尾
+func Match(pattern, path string) bool {
+	end := strings.HasSuffix(pattern, "$")
+	parts := strings.Split(strings.TrimSuffix(pattern, "$"), "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	rest := path[len(parts[0]):]
+	if len(parts) == 1 {
+		return !end || rest == ""
+	}
+
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(rest, part)
+		if i < 0 {
+			return false
+		}
+		rest = rest[i+len(part):]
+	}
+	if end {
+		return strings.HasSuffix(rest, last)
+	}
+	return strings.Contains(rest, last)
+}
@@ -0,0 +1,112 @@
+package robots
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cnk3x/go/urlx"
+)
+
+func TestParse(t *testing.T) {
+	r, err := Parse(strings.NewReader(`
+User-agent: BadBot
+User-agent: OtherBot
+Disallow: /
+
+User-agent: *
+Disallow: /*.php$
+Disallow: /tmp
+Allow: /tmp/ok
+Crawl-delay: 1.5
+
+Sitemap: https://example.com/sitemap.xml
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Sitemaps) != 1 || r.CrawlDelay("urlx") != 1500e6 || r.Allowed("Mozilla/5.0 (compatible; BadBot/1.0)", "/x") {
+		t.Fatalf("parse: %+v", r)
+	}
+	for path, want := range map[string]bool{
+		"/index.html": true,
+		"/a.php":      false,
+		"/a.php?x=1":  true,
+		"/a.php.php":  false,
+		"/tmp/x":      false,
+		"/tmp/ok/x":   true,
+		"/x/tmp/ok":   true,
+	} {
+		if got := r.Allowed("urlx", path); got != want {
+			t.Errorf("%s: got %v want %v", path, got, want)
+		}
+	}
+}
+
+func TestGroupAllowAll(t *testing.T) {
+	// 没有匹配的组时返回新的空组，修改不影响其他调用
+	r := &Robots{}
+	g := r.Group("a")
+	g.Rules = append(g.Rules, Rule{Pattern: "/"})
+	if g = r.Group("b"); len(g.Rules) != 0 || !r.Allowed("b", "/x") {
+		t.Fatalf("shared group modified: %+v", g)
+	}
+}
+
+func TestEnforce(t *testing.T) {
+	var fetched, moved, private int32
+	listen, _ := net.Listen("tcp", "127.0.0.1:0")
+	s := &http.Server{Handler: http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			atomic.AddInt32(&fetched, 1)
+			fmt.Fprint(rw, "User-agent: BadBot\nDisallow: /\n\nUser-agent: *\nDisallow: /private\n")
+		}
+		switch {
+		case r.URL.Path == "/moved":
+			atomic.AddInt32(&moved, 1)
+			http.Redirect(rw, r, "/private/y", http.StatusFound)
+		case strings.HasPrefix(r.URL.Path, "/private"):
+			atomic.AddInt32(&private, 1)
+		}
+	})}
+	go func() { _ = s.Serve(listen) }()
+	defer s.Shutdown(context.TODO())
+	addr := "http://" + listen.Addr().String()
+
+	cache := NewCache(nil, 0)
+	err := urlx.MacEdge(context.TODO()).Url(addr + "/private/x").With(cache.Enforce()).Process(nil)
+	var de *DisallowedError
+	if !errors.Is(err, ErrDisallowed) || !errors.As(err, &de) || !strings.Contains(de.Agent, "Edg/") {
+		t.Fatalf("want disallowed, got %v", err)
+	}
+	if err = urlx.MacEdge(context.TODO()).Url(addr + "/public").With(cache.Enforce()).Process(nil); err != nil {
+		t.Fatal(err)
+	}
+	// 之后的选项设置的 User-Agent 同样生效
+	setAgent := func(r *urlx.Request) error {
+		r.HeaderWith(urlx.UserAgent("BadBot/1.0"))
+		return nil
+	}
+	err = urlx.MacEdge(context.TODO()).Url(addr+"/public").With(cache.Enforce(), setAgent).Process(nil)
+	if !errors.As(err, &de) || de.Agent != "BadBot/1.0" {
+		t.Fatalf("want disallowed for BadBot, got %v", err)
+	}
+
+	// 重定向到不允许的地址时同样拦截，且不重试
+	err = urlx.MacEdge(context.TODO()).Url(addr + "/moved").TryAt(time.Millisecond).With(cache.Enforce()).Process(nil)
+	if !errors.As(err, &de) || !strings.HasSuffix(de.URL, "/private/y") {
+		t.Fatalf("want disallowed redirect, got %v", err)
+	}
+	if private != 0 || moved != 1 {
+		t.Fatalf("disallowed path requested %d times, redirect %d times", private, moved)
+	}
+	if fetched != 1 {
+		t.Fatalf("robots.txt fetched %d times", fetched)
+	}
+}
@@ -0,0 +1,60 @@
+package urlx
+
+import (
+	"context"
+	"net/http"
+)
+
+// Session 会话，持久化的 Cookie 容器加上浏览器默认设置，多次运行之间复用登录状态
+type Session struct {
+	jar     *CookieJar
+	browser *Browser
+	client  *http.Client
+	file    string
+}
+
+// NewSession 创建会话，file 不为空时从文件加载 Cookie，并在 Save 时写回
+//
+// 文件扩展名为 .json 时使用 JSON 格式，否则为 Netscape cookies.txt 格式
+func NewSession(file string, candidates ...BrowserProfile) (*Session, error) {
+	jar := NewCookieJar(nil)
+	if file != "" {
+		if err := jar.Load(file); err != nil {
+			return nil, err
+		}
+	}
+	return &Session{
+		jar:     jar,
+		browser: Browse(candidates...),
+		client:  &http.Client{Jar: jar},
+		file:    file,
+	}, nil
+}
+
+// Jar 会话的 Cookie 容器
+func (s *Session) Jar() *CookieJar {
+	return s.jar
+}
+
+// Browser 会话的浏览器设置
+func (s *Session) Browser() *Browser {
+	return s.browser
+}
+
+// Client 会话共享的客户端，设置了 Cookie 容器
+func (s *Session) Client() *http.Client {
+	return s.client
+}
+
+// New 以会话的浏览器设置和 Cookie 开始一个请求
+func (s *Session) New(ctx context.Context) *Request {
+	return s.browser.New(ctx).UseClient(s.client)
+}
+
+// Save 将 Cookie 保存到会话文件
+func (s *Session) Save() error {
+	if s.file == "" {
+		return nil
+	}
+	return s.jar.Save(s.file)
+}
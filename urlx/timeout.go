@@ -0,0 +1,172 @@
+package urlx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+var ErrTimeout = errors.New("request: timeout")
+
+// TimeoutKind 超时类型
+type TimeoutKind int
+
+const (
+	TimeoutOverall TimeoutKind = iota // 整个请求，包含重试和读取响应
+	TimeoutAttempt                    // 单次尝试，到收到响应头为止
+	TimeoutIdle                       // 读取响应时持续没有数据
+)
+
+func (k TimeoutKind) String() string {
+	switch k {
+	case TimeoutAttempt:
+		return "attempt"
+	case TimeoutIdle:
+		return "idle"
+	default:
+		return "overall"
+	}
+}
+
+// TimeoutError 超时错误，errors.Is(err, ErrTimeout) 为真，与 context.Canceled 区分开
+type TimeoutError struct {
+	Kind     TimeoutKind
+	Duration time.Duration
+	Err      error // 超时后底层返回的错误
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("%s (%s %s): %v", ErrTimeout, e.Kind, e.Duration, e.Err)
+}
+
+func (e *TimeoutError) Unwrap() error { return ErrTimeout }
+
+// Timeout 实现 net.Error，单次尝试超时可以重试
+func (e *TimeoutError) Timeout() bool { return true }
+
+// Temporary 实现 net.Error
+func (e *TimeoutError) Temporary() bool { return e.Kind == TimeoutAttempt }
+
+type timeouts struct {
+	overall time.Duration
+	attempt time.Duration
+	idle    time.Duration
+}
+
+// Timeout 整个请求的超时，包含所有重试和读取响应，独立于 ctx 的截止时间
+func (c *Request) Timeout(d time.Duration) *Request {
+	c.timeouts.overall = d
+	return c
+}
+
+// Timeout 整个请求超时的选项
+func Timeout(d time.Duration) Option {
+	return func(c *Request) error {
+		c.Timeout(d)
+		return nil
+	}
+}
+
+// AttemptTimeout 单次尝试等待响应头的超时，超时后按重试设置重试
+func (c *Request) AttemptTimeout(d time.Duration) *Request {
+	c.timeouts.attempt = d
+	return c
+}
+
+// AttemptTimeout 单次尝试超时的选项
+func AttemptTimeout(d time.Duration) Option {
+	return func(c *Request) error {
+		c.AttemptTimeout(d)
+		return nil
+	}
+}
+
+// IdleTimeout 读取响应时持续 d 没有收到数据则中断，适合长时间的下载
+func (c *Request) IdleTimeout(d time.Duration) *Request {
+	c.timeouts.idle = d
+	return c
+}
+
+// IdleTimeout 读取空闲超时的选项
+func IdleTimeout(d time.Duration) Option {
+	return func(c *Request) error {
+		c.IdleTimeout(d)
+		return nil
+	}
+}
+
+// deadline 到时取消 Context 并记录，用以区分超时和调用方的取消
+type deadline struct {
+	fired int32
+	timer *time.Timer
+}
+
+func newDeadline(d time.Duration, cancel context.CancelFunc) *deadline {
+	t := &deadline{}
+	t.timer = time.AfterFunc(d, func() {
+		atomic.StoreInt32(&t.fired, 1)
+		cancel()
+	})
+	return t
+}
+
+func (t *deadline) expired() bool {
+	return t != nil && atomic.LoadInt32(&t.fired) == 1
+}
+
+func (t *deadline) stop() {
+	if t != nil {
+		t.timer.Stop()
+	}
+}
+
+// attemptTimeout 单次尝试超时的中间件，收到响应头后停止计时
+func attemptTimeout(d time.Duration) Middleware {
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			ctx, cancel := context.WithCancel(req.Context())
+			t := newDeadline(d, cancel)
+			resp, err := next.Do(req.WithContext(ctx))
+			t.stop()
+			if err != nil {
+				cancel()
+				if t.expired() {
+					return nil, &TimeoutError{Kind: TimeoutAttempt, Duration: d, Err: err}
+				}
+				return nil, err
+			}
+			resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+			return resp, nil
+		})
+	}
+}
+
+// idleReader 每次读取时计时，持续没有数据则取消请求
+type idleReader struct {
+	r io.Reader
+	d time.Duration
+	t *deadline
+}
+
+func newIdleReader(r io.Reader, d time.Duration, cancel context.CancelFunc) *idleReader {
+	t := newDeadline(d, cancel)
+	t.stop()
+	return &idleReader{r: r, d: d, t: t}
+}
+
+func (r *idleReader) Read(p []byte) (n int, err error) {
+	if r.t.expired() {
+		return 0, &TimeoutError{Kind: TimeoutIdle, Duration: r.d, Err: context.Canceled}
+	}
+	r.t.timer.Reset(r.d)
+	n, err = r.r.Read(p)
+	r.t.stop()
+	if err != nil && err != io.EOF && r.t.expired() {
+		err = &TimeoutError{Kind: TimeoutIdle, Duration: r.d, Err: err}
+	}
+	return
+}
@@ -0,0 +1,65 @@
+package urlx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTimeouts(t *testing.T) {
+	var hits int32
+	addr, closer := mockHTTPServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/flaky":
+			if atomic.AddInt32(&hits, 1) == 1 {
+				<-r.Context().Done()
+				return
+			}
+			_, _ = rw.Write([]byte("ok"))
+		case "/slow":
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+		case "/stall":
+			_, _ = rw.Write([]byte("head"))
+			rw.(http.Flusher).Flush()
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+		case "/trickle":
+			for i := 0; i < 5; i++ {
+				_, _ = rw.Write([]byte("x"))
+				rw.(http.Flusher).Flush()
+				time.Sleep(20 * time.Millisecond)
+			}
+		}
+	}))
+	defer closer()
+
+	var te *TimeoutError
+
+	data, err := Default(nil).Url(addr + "/flaky").AttemptTimeout(50 * time.Millisecond).TryAt(time.Millisecond).Bytes()
+	eq(t, [][2]any{{err, nil}, {string(data), "ok"}, {atomic.LoadInt32(&hits), int32(2)}})
+
+	_, err = Default(nil).Url(addr + "/slow").With(AttemptTimeout(30 * time.Millisecond)).Bytes()
+	eq(t, [][2]any{{errors.As(err, &te), true}, {te.Kind, TimeoutAttempt}, {errors.Is(err, context.Canceled), false}})
+
+	_, err = Default(nil).Url(addr+"/slow").Timeout(30*time.Millisecond).TryAt(time.Millisecond, time.Millisecond).Bytes()
+	eq(t, [][2]any{{errors.As(err, &te), true}, {te.Kind, TimeoutOverall}, {errors.Is(err, ErrTimeout), true}})
+
+	_, err = Default(nil).Url(addr + "/stall").IdleTimeout(30 * time.Millisecond).Bytes()
+	eq(t, [][2]any{{errors.As(err, &te), true}, {te.Kind, TimeoutIdle}})
+
+	data, err = Default(nil).Url(addr + "/trickle").IdleTimeout(60 * time.Millisecond).Bytes()
+	eq(t, [][2]any{{err, nil}, {string(data), "xxxxx"}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	_, err = Default(ctx).Url(addr + "/slow").Timeout(time.Second).Bytes()
+	eq(t, [][2]any{{errors.Is(err, ErrTimeout), false}, {errors.Is(err, context.DeadlineExceeded), true}})
+}
@@ -0,0 +1,79 @@
+package tus
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Store 记录 Fingerprint 对应的上传地址，用于中断后继续上传
+type Store interface {
+	Get(fingerprint string) (url string, ok bool)
+	Set(fingerprint, url string) error
+	Delete(fingerprint string)
+}
+
+// NewMemoryStore 内存中的 Store，只在进程内有效
+func NewMemoryStore() Store {
+	return &memoryStore{urls: map[string]string{}}
+}
+
+type memoryStore struct {
+	mu   sync.Mutex
+	urls map[string]string
+}
+
+func (s *memoryStore) Get(fingerprint string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	url, ok := s.urls[fingerprint]
+	return url, ok
+}
+
+func (s *memoryStore) Set(fingerprint, url string) error {
+	s.mu.Lock()
+	s.urls[fingerprint] = url
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *memoryStore) Delete(fingerprint string) {
+	s.mu.Lock()
+	delete(s.urls, fingerprint)
+	s.mu.Unlock()
+}
+
+// DirStore 以目录保存的 Store，每个 Fingerprint 一个文件，进程重启后可以继续上传
+func DirStore(dir string) Store {
+	return dirStore(dir)
+}
+
+type dirStore string
+
+func (s dirStore) path(fingerprint string) string {
+	sum := sha1.Sum([]byte(fingerprint))
+	return filepath.Join(string(s), hex.EncodeToString(sum[:]))
+}
+
+func (s dirStore) Get(fingerprint string) (string, bool) {
+	data, err := os.ReadFile(s.path(fingerprint))
+	if err != nil {
+		return "", false
+	}
+	url := strings.TrimSpace(string(data))
+	return url, url != ""
+}
+
+func (s dirStore) Set(fingerprint, url string) error {
+	if err := os.MkdirAll(string(s), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(s.path(fingerprint), []byte(url+"\n"), 0o644)
+}
+
+func (s dirStore) Delete(fingerprint string) {
+	_ = os.Remove(s.path(fingerprint))
+}
@@ -0,0 +1,306 @@
+// Package tus 基于 urlx 的 tus 1.0 断点续传上传客户端
+//
+//	c := tus.New("https://example.com/files/", urlx.Default(ctx).TryAt(time.Second)).Resume(tus.DirStore(".uploads"))
+//	location, err := c.UploadFile(ctx, "video.mp4", func(offset, size int64) { ... })
+package tus
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cnk3x/go/urlx"
+)
+
+// Version 支持的协议版本
+const Version = "1.0.0"
+
+var ErrProtocol = errors.New("tus: protocol error")
+
+// errConflict 服务端的偏移量与请求不一致
+var errConflict = errors.New("tus: offset conflict")
+
+// Upload 一个上传
+type Upload struct {
+	Fingerprint string                   // 在 Store 中记录上传地址的键，为空时不记录
+	Metadata    map[string]string        // 以 Upload-Metadata 发送，如 filename、filetype
+	Progress    func(offset, size int64) // 每上传一块后回调
+	URL         string                   // 上传地址，创建上传后设置，设置时从此地址继续
+
+	reader io.ReaderAt
+	size   int64
+}
+
+// NewUpload 上传 r 中的 size 字节
+func NewUpload(r io.ReaderAt, size int64, metadata map[string]string) *Upload {
+	return &Upload{reader: r, size: size, Metadata: metadata}
+}
+
+// Size 上传的总字节数
+func (u *Upload) Size() int64 {
+	return u.size
+}
+
+// Client tus 客户端
+type Client struct {
+	endpoint   string
+	req        *urlx.Request
+	chunkSize  int64
+	store      Store
+	maxResumes int
+}
+
+// New 创建客户端，endpoint 为创建上传的地址，每次请求以 req 为模板复制，为 nil 时使用 urlx.Default
+func New(endpoint string, req *urlx.Request) *Client {
+	if req == nil {
+		req = urlx.Default(nil)
+	}
+	return &Client{endpoint: endpoint, req: req, chunkSize: 4 << 20, maxResumes: 3}
+}
+
+// ChunkSize 每个 PATCH 请求上传的字节数，默认 4MB
+func (c *Client) ChunkSize(n int64) *Client {
+	if n > 0 {
+		c.chunkSize = n
+	}
+	return c
+}
+
+// Resume 以 store 记录上传地址，同一个 Fingerprint 的上传从服务端已有的偏移量继续
+func (c *Client) Resume(store Store) *Client {
+	c.store = store
+	return c
+}
+
+// MaxResumes 上传出错后向服务端查询偏移量继续的最多次数，有进展后重新计数，默认 3
+func (c *Client) MaxResumes(n int) *Client {
+	c.maxResumes = n
+	return c
+}
+
+// UploadFile 上传文件，以路径、大小和修改时间作为 Fingerprint，返回上传地址
+func (c *Client) UploadFile(ctx context.Context, path string, progress func(offset, size int64)) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	abs, _ := filepath.Abs(path)
+
+	metadata := map[string]string{"filename": filepath.Base(path)}
+	if filetype := mime.TypeByExtension(filepath.Ext(path)); filetype != "" {
+		metadata["filetype"] = filetype
+	}
+	u := NewUpload(f, stat.Size(), metadata)
+	u.Fingerprint = fmt.Sprintf("file:%s:%d:%d", abs, stat.Size(), stat.ModTime().UnixNano())
+	u.Progress = progress
+	err = c.Upload(ctx, u)
+	return u.URL, err
+}
+
+// Upload 上传，已有上传地址时从服务端的偏移量继续，否则创建上传。
+//
+// 上传出错时查询服务端的偏移量继续，超过 MaxResumes 后返回错误，再次调用 Upload 可以继续
+func (c *Client) Upload(ctx context.Context, u *Upload) (err error) {
+	if u.URL == "" && u.Fingerprint != "" && c.store != nil {
+		u.URL, _ = c.store.Get(u.Fingerprint)
+	}
+
+	var offset int64
+	if u.URL != "" {
+		if offset, err = c.offset(ctx, u); err != nil {
+			return err
+		}
+		if offset < 0 {
+			// 上传已不存在，重新创建
+			c.forget(u)
+			u.URL = ""
+		}
+	}
+	if u.URL == "" {
+		if err = c.create(ctx, u); err != nil {
+			return err
+		}
+		offset = 0
+	}
+	if u.Progress != nil {
+		u.Progress(offset, u.size)
+	}
+
+	failures := 0
+	for offset < u.size {
+		next, err := c.patch(ctx, u, offset)
+		if err == nil {
+			offset, failures = next, 0
+			if u.Progress != nil {
+				u.Progress(offset, u.size)
+			}
+			continue
+		}
+
+		var se *urlx.StatusError
+		if ctx.Err() != nil || (errors.As(err, &se) && !errors.Is(err, errConflict)) {
+			return err
+		}
+		if failures++; failures > c.maxResumes {
+			return err
+		}
+		log.Printf("tus: %s 在 %d 处出错: %v, 从服务端的偏移量继续", u.URL, offset, err)
+		if offset, err = c.offset(ctx, u); err != nil {
+			return err
+		}
+		if offset < 0 {
+			return fmt.Errorf("%w: upload %s is gone", ErrProtocol, u.URL)
+		}
+	}
+
+	c.forget(u)
+	return nil
+}
+
+func (c *Client) forget(u *Upload) {
+	if u.Fingerprint != "" && c.store != nil {
+		c.store.Delete(u.Fingerprint)
+	}
+}
+
+func (c *Client) request(ctx context.Context, method, url string) *urlx.Request {
+	return c.req.WithContext(ctx).Url(url).Method(method).HeaderWith(urlx.HeaderSet("Tus-Resumable", Version))
+}
+
+// do 发送请求，读取并丢弃响应内容
+func do(r *urlx.Request) (resp *http.Response, body []byte, err error) {
+	err = r.Process(func(res *http.Response, rc io.ReadCloser) error {
+		defer rc.Close()
+		resp = res
+		body, _ = io.ReadAll(io.LimitReader(rc, 64<<10))
+		return nil
+	})
+	return
+}
+
+func statusError(resp *http.Response, body []byte) error {
+	return &urlx.StatusError{StatusCode: resp.StatusCode, Status: resp.Status, Body: body}
+}
+
+// create 创建上传，POST 到 endpoint，响应的 Location 为上传地址
+func (c *Client) create(ctx context.Context, u *Upload) error {
+	r := c.request(ctx, http.MethodPost, c.endpoint).HeaderWith(urlx.HeaderSet("Upload-Length", strconv.FormatInt(u.size, 10)))
+	if metadata := encodeMetadata(u.Metadata); metadata != "" {
+		r.HeaderWith(urlx.HeaderSet("Upload-Metadata", metadata))
+	}
+	resp, body, err := do(r)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusCreated {
+		return statusError(resp, body)
+	}
+	location, err := resp.Request.URL.Parse(resp.Header.Get("Location"))
+	if err != nil || resp.Header.Get("Location") == "" {
+		return fmt.Errorf("%w: invalid Location %q", ErrProtocol, resp.Header.Get("Location"))
+	}
+
+	u.URL = location.String()
+	if u.Fingerprint != "" && c.store != nil {
+		if err = c.store.Set(u.Fingerprint, u.URL); err != nil {
+			log.Printf("tus: 记录上传地址出错: %v", err)
+		}
+	}
+	return nil
+}
+
+// offset 以 HEAD 查询服务端的偏移量，上传不存在时返回 -1，超过上传的大小时返回 ErrProtocol
+func (c *Client) offset(ctx context.Context, u *Upload) (int64, error) {
+	resp, body, err := do(c.request(ctx, http.MethodHead, u.URL))
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound, resp.StatusCode == http.StatusGone, resp.StatusCode == http.StatusForbidden:
+		return -1, nil
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return 0, statusError(resp, body)
+	}
+	offset, err := parseOffset(resp)
+	if err == nil && offset > u.size {
+		err = fmt.Errorf("%w: offset %d exceeds upload size %d", ErrProtocol, offset, u.size)
+	}
+	return offset, err
+}
+
+// patch 从 offset 上传一块，返回服务端新的偏移量
+func (c *Client) patch(ctx context.Context, u *Upload, offset int64) (int64, error) {
+	n := u.size - offset
+	if n > c.chunkSize {
+		n = c.chunkSize
+	}
+	r := c.request(ctx, http.MethodPatch, u.URL).
+		HeaderWith(urlx.HeaderSet("Upload-Offset", strconv.FormatInt(offset, 10))).
+		SendBody(func() (contentType string, body io.Reader, err error) {
+			return "application/offset+octet-stream", io.NewSectionReader(u.reader, offset, n), nil
+		}).
+		Use(contentLength(n))
+	resp, body, err := do(r)
+	if err != nil {
+		return offset, err
+	}
+	switch {
+	case resp.StatusCode == http.StatusConflict:
+		return offset, fmt.Errorf("%w: %v", errConflict, statusError(resp, body))
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return offset, statusError(resp, body)
+	}
+
+	next, err := parseOffset(resp)
+	if err == nil && (next <= offset || next > u.size) {
+		err = fmt.Errorf("%w: offset %d after patch at %d", ErrProtocol, next, offset)
+	}
+	return next, err
+}
+
+// contentLength 块的大小已知，以 Content-Length 发送
+func contentLength(n int64) urlx.Middleware {
+	return func(next urlx.Doer) urlx.Doer {
+		return urlx.DoerFunc(func(req *http.Request) (*http.Response, error) {
+			req.ContentLength = n
+			return next.Do(req)
+		})
+	}
+}
+
+func parseOffset(resp *http.Response) (int64, error) {
+	offset, err := strconv.ParseInt(resp.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("%w: invalid Upload-Offset %q", ErrProtocol, resp.Header.Get("Upload-Offset"))
+	}
+	return offset, nil
+}
+
+// encodeMetadata 按键排序，值以 base64 编码
+func encodeMetadata(metadata map[string]string) string {
+	keys := make([]string, 0, len(metadata))
+	for k := range metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = k + " " + base64.StdEncoding.EncodeToString([]byte(metadata[k]))
+	}
+	return strings.Join(pairs, ",")
+}
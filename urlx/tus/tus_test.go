@@ -0,0 +1,203 @@
+package tus
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type testFile struct {
+	length   int64
+	metadata map[string]string
+	data     []byte
+}
+
+// testServer 内存中的 tus 服务端，PATCH 写到 abortAt 时中断连接
+type testServer struct {
+	mu      sync.Mutex
+	files   map[string]*testFile
+	posts   int
+	aborts  int
+	abortAt int64
+}
+
+func newTestServer() *testServer {
+	return &testServer{files: map[string]*testFile{}}
+}
+
+func (s *testServer) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Tus-Resumable") != Version {
+		rw.WriteHeader(http.StatusPreconditionFailed)
+		return
+	}
+	rw.Header().Set("Tus-Resumable", Version)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.Method == http.MethodPost && r.URL.Path == "/files/" {
+		length, err := strconv.ParseInt(r.Header.Get("Upload-Length"), 10, 64)
+		if err != nil {
+			rw.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f := &testFile{length: length, metadata: map[string]string{}}
+		for _, pair := range strings.Split(r.Header.Get("Upload-Metadata"), ",") {
+			if kv := strings.SplitN(pair, " ", 2); len(kv) == 2 {
+				v, _ := base64.StdEncoding.DecodeString(kv[1])
+				f.metadata[kv[0]] = string(v)
+			}
+		}
+		s.posts++
+		id := strconv.Itoa(len(s.files) + 1)
+		s.files[id] = f
+		rw.Header().Set("Location", id) // 相对地址
+		rw.WriteHeader(http.StatusCreated)
+		return
+	}
+
+	f := s.files[strings.TrimPrefix(r.URL.Path, "/files/")]
+	if f == nil {
+		rw.WriteHeader(http.StatusNotFound)
+		return
+	}
+	switch r.Method {
+	case http.MethodHead:
+		rw.Header().Set("Upload-Offset", strconv.Itoa(len(f.data)))
+		rw.Header().Set("Upload-Length", strconv.FormatInt(f.length, 10))
+		rw.Header().Set("Cache-Control", "no-store")
+	case http.MethodPatch:
+		if r.Header.Get("Content-Type") != "application/offset+octet-stream" {
+			rw.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+		if r.ContentLength <= 0 {
+			rw.WriteHeader(http.StatusLengthRequired)
+			return
+		}
+		if offset, _ := strconv.Atoi(r.Header.Get("Upload-Offset")); offset != len(f.data) {
+			rw.WriteHeader(http.StatusConflict)
+			return
+		}
+		data, err := io.ReadAll(r.Body)
+		if end := int64(len(f.data) + len(data)); s.abortAt > int64(len(f.data)) && s.abortAt < end {
+			// 只收到一部分内容时连接断开
+			s.aborts++
+			f.data = append(f.data, data[:s.abortAt-int64(len(f.data))]...)
+			panic(http.ErrAbortHandler)
+		}
+		if err != nil || int64(len(f.data)+len(data)) > f.length {
+			rw.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.data = append(f.data, data...)
+		rw.Header().Set("Upload-Offset", strconv.Itoa(len(f.data)))
+		rw.WriteHeader(http.StatusNoContent)
+	default:
+		rw.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestUpload(t *testing.T) {
+	s := newTestServer()
+	s.abortAt = 50
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	data := bytes.Repeat([]byte("0123456789"), 10)
+	store := NewMemoryStore()
+	c := New(ts.URL+"/files/", nil).ChunkSize(32).Resume(store)
+
+	var offsets []int64
+	u := NewUpload(bytes.NewReader(data), int64(len(data)), map[string]string{"filename": "a.txt"})
+	u.Fingerprint = "a"
+	u.Progress = func(offset, size int64) { offsets = append(offsets, offset) }
+	if err := c.Upload(context.Background(), u); err != nil {
+		t.Fatal(err)
+	}
+
+	f := s.files["1"]
+	if !bytes.Equal(f.data, data) || f.metadata["filename"] != "a.txt" {
+		t.Fatalf("server got %q %v", f.data, f.metadata)
+	}
+	if u.URL != ts.URL+"/files/1" || s.posts != 1 || s.aborts != 1 {
+		t.Fatalf("url %s, posts %d, aborts %d", u.URL, s.posts, s.aborts)
+	}
+	// 32 之后的块在 50 处中断，从服务端的 50 继续
+	if got := fmt.Sprint(offsets); got != "[0 32 82 100]" {
+		t.Fatalf("progress %s", got)
+	}
+	if _, ok := store.Get("a"); ok {
+		t.Fatal("fingerprint should be removed after upload")
+	}
+
+	// 记录的上传已不存在时重新创建
+	_ = store.Set("gone", ts.URL+"/files/404")
+	u = NewUpload(bytes.NewReader(data[:10]), 10, nil)
+	u.Fingerprint = "gone"
+	if err := c.Upload(context.Background(), u); err != nil {
+		t.Fatal(err)
+	}
+	if u.URL != ts.URL+"/files/2" || string(s.files["2"].data) != "0123456789" {
+		t.Fatalf("recreated upload %s", u.URL)
+	}
+
+	// 服务端的偏移量超过上传的大小
+	u = NewUpload(bytes.NewReader(data[:5]), 5, nil)
+	u.URL = ts.URL + "/files/2"
+	if err := c.Upload(context.Background(), u); !errors.Is(err, ErrProtocol) {
+		t.Fatalf("err = %v, want ErrProtocol", err)
+	}
+}
+
+func TestUploadFileResume(t *testing.T) {
+	s := newTestServer()
+	s.abortAt = 40
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "video.txt")
+	data := bytes.Repeat([]byte("abcdefghij"), 10)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	store := DirStore(filepath.Join(dir, "uploads"))
+
+	// 不自动继续时返回错误，上传地址保留在 Store 中
+	_, err := New(ts.URL+"/files/", nil).ChunkSize(64).MaxResumes(0).Resume(store).UploadFile(context.Background(), path, nil)
+	if err == nil {
+		t.Fatal("want error")
+	}
+
+	// 新的客户端从服务端的偏移量继续，不再创建上传
+	var first int64 = -1
+	location, err := New(ts.URL+"/files/", nil).ChunkSize(64).Resume(store).UploadFile(context.Background(), path, func(offset, size int64) {
+		if first < 0 {
+			first = offset
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := s.files["1"]
+	if location != ts.URL+"/files/1" || !bytes.Equal(f.data, data) || s.posts != 1 || first != 40 {
+		t.Fatalf("location %s, posts %d, first offset %d, data %q", location, s.posts, first, f.data)
+	}
+	if f.metadata["filename"] != "video.txt" || !strings.HasPrefix(f.metadata["filetype"], "text/plain") {
+		t.Fatalf("metadata %v", f.metadata)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(dir, "uploads")); len(entries) != 0 {
+		t.Fatalf("store should be empty, got %d entries", len(entries))
+	}
+}
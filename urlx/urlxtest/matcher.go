@@ -0,0 +1,37 @@
+package urlxtest
+
+import (
+	"bytes"
+	"reflect"
+	"regexp"
+
+	"github.com/goccy/go-json"
+)
+
+// BodyEquals 请求内容等于 s
+func BodyEquals(s string) BodyMatcher {
+	return func(body []byte) bool { return string(body) == s }
+}
+
+// BodyContains 请求内容包含 s
+func BodyContains(s string) BodyMatcher {
+	return func(body []byte) bool { return bytes.Contains(body, []byte(s)) }
+}
+
+// BodyRegexp 请求内容匹配正则表达式
+func BodyRegexp(expr string) BodyMatcher {
+	re := regexp.MustCompile(expr)
+	return re.Match
+}
+
+// BodyJSON 请求内容与 JSON 文本 s 解码后相等，忽略空白和对象键的顺序
+func BodyJSON(s string) BodyMatcher {
+	var want any
+	if err := json.Unmarshal([]byte(s), &want); err != nil {
+		panic("urlxtest: invalid json " + s)
+	}
+	return func(body []byte) bool {
+		var got any
+		return json.Unmarshal(body, &got) == nil && reflect.DeepEqual(got, want)
+	}
+}
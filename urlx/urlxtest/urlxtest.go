@@ -0,0 +1,307 @@
+// Package urlxtest 内存中的 http.RoundTripper，按期望返回预设的响应，用于不监听端口的单元测试
+//
+//	mt := urlxtest.New()
+//	mt.Expect("GET", "/users/*").Header("Authorization", "Bearer x").Reply(200, `{"id":1}`)
+//	mt.Expect("POST", "https://api.example.com/items").Body(urlxtest.BodyContains("name")).
+//		Fail(io.ErrUnexpectedEOF).Reply(201, "")
+//
+//	err := urlx.Default(ctx).UseClient(mt.Client()).TryAt(0).Url(...).Process(...)
+//	mt.AssertExpectations(t)
+package urlxtest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cnk3x/go/urlx"
+)
+
+var ErrUnexpected = errors.New("urlxtest: unexpected request")
+
+// Transport 按期望处理请求的 http.RoundTripper，可以并发使用
+type Transport struct {
+	mu           sync.Mutex
+	expectations []*Expectation
+	unexpected   []string
+}
+
+// New 创建 Transport
+func New() *Transport {
+	return &Transport{}
+}
+
+// Client 使用此 Transport 的客户端，不保存 Cookie
+func (t *Transport) Client() *http.Client {
+	return &http.Client{Transport: t}
+}
+
+// Option 使用此 Transport 的请求选项
+func (t *Transport) Option() urlx.Option {
+	return urlx.UseClient(t.Client())
+}
+
+// Expect 增加一个期望，method 为空或 * 时匹配任意方法。
+//
+// pattern 以 / 开头时匹配链接的路径，否则匹配不含参数的完整链接，* 匹配一段路径中的任意字符，
+// 含有 ? 时还要求链接包含其后的全部参数
+func (t *Transport) Expect(method, pattern string) *Expectation {
+	e := &Expectation{mu: &t.mu, method: strings.ToUpper(method), pattern: pattern}
+	if i := strings.IndexByte(pattern, '?'); i >= 0 {
+		e.pattern = pattern[:i]
+		for _, kv := range strings.Split(pattern[i+1:], "&") {
+			k, v := kv, ""
+			if j := strings.IndexByte(kv, '='); j >= 0 {
+				k, v = kv[:j], kv[j+1:]
+			}
+			e.Query(k, v)
+		}
+	}
+	t.mu.Lock()
+	t.expectations = append(t.expectations, e)
+	t.mu.Unlock()
+	return e
+}
+
+// RoundTrip 实现 http.RoundTripper，交给第一个匹配且次数未用完的期望处理，没有时返回 ErrUnexpected
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	t.mu.Lock()
+	var matched *Expectation
+	var step step
+	for _, e := range t.expectations {
+		if e.match(req, body) {
+			matched, step = e, e.next()
+			break
+		}
+	}
+	if matched == nil {
+		t.unexpected = append(t.unexpected, req.Method+" "+req.URL.String())
+	}
+	t.mu.Unlock()
+
+	if matched == nil {
+		return nil, fmt.Errorf("%w: %s %s", ErrUnexpected, req.Method, req.URL)
+	}
+	return step.do(req)
+}
+
+// AssertExpectations 检查每个期望都按次数被调用，且没有意外的请求
+func (t *Transport) AssertExpectations(tb testing.TB) {
+	tb.Helper()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, e := range t.expectations {
+		switch {
+		case e.times > 0 && e.calls != e.times:
+			tb.Errorf("urlxtest: %s called %d times, want %d", e, e.calls, e.times)
+		case e.times == 0 && e.calls == 0:
+			tb.Errorf("urlxtest: %s was not called", e)
+		}
+	}
+	for _, r := range t.unexpected {
+		tb.Errorf("urlxtest: unexpected request %s", r)
+	}
+}
+
+// BodyMatcher 请求内容匹配
+type BodyMatcher = func(body []byte) bool
+
+// Expectation 一个期望，匹配条件和依次返回的响应，最后一个响应重复使用
+type Expectation struct {
+	mu       *sync.Mutex
+	method   string
+	pattern  string
+	matchers []func(req *http.Request, body []byte) bool
+	steps    []step
+	times    int
+	calls    int
+}
+
+func (e *Expectation) String() string {
+	method := e.method
+	if method == "" {
+		method = "*"
+	}
+	return method + " " + e.pattern
+}
+
+// Header 要求请求头的值
+func (e *Expectation) Header(key, value string) *Expectation {
+	return e.Match(func(req *http.Request, body []byte) bool { return req.Header.Get(key) == value })
+}
+
+// Query 要求链接参数的值
+func (e *Expectation) Query(key, value string) *Expectation {
+	return e.Match(func(req *http.Request, body []byte) bool {
+		values, ok := req.URL.Query()[key]
+		return ok && values[0] == value
+	})
+}
+
+// Body 要求请求内容匹配
+func (e *Expectation) Body(matcher BodyMatcher) *Expectation {
+	return e.Match(func(req *http.Request, body []byte) bool { return matcher(body) })
+}
+
+// Match 自定义的匹配条件
+func (e *Expectation) Match(fn func(req *http.Request, body []byte) bool) *Expectation {
+	e.matchers = append(e.matchers, fn)
+	return e
+}
+
+// Times 期望调用的次数，达到后不再匹配，默认不限次数但至少一次
+func (e *Expectation) Times(n int) *Expectation {
+	e.times = n
+	return e
+}
+
+// Once 期望调用一次
+func (e *Expectation) Once() *Expectation {
+	return e.Times(1)
+}
+
+// Reply 增加一个响应
+func (e *Expectation) Reply(status int, body string) *Expectation {
+	e.steps = append(e.steps, step{status: status, body: body, header: http.Header{}})
+	return e
+}
+
+// ReplyHeader 增加最后一个响应的响应头，还没有响应时增加一个 200 响应
+func (e *Expectation) ReplyHeader(key, value string) *Expectation {
+	if len(e.steps) == 0 {
+		e.Reply(http.StatusOK, "")
+	}
+	last := &e.steps[len(e.steps)-1]
+	if last.header == nil {
+		last.header = http.Header{}
+	}
+	last.header.Add(key, value)
+	return e
+}
+
+// Respond 增加一个以函数生成的响应，fn 可以读取请求内容
+func (e *Expectation) Respond(fn func(req *http.Request) (*http.Response, error)) *Expectation {
+	e.steps = append(e.steps, step{fn: fn})
+	return e
+}
+
+// Fail 增加一次网络错误，客户端返回的 *url.Error 实现 net.Error，TryAt 会重试
+func (e *Expectation) Fail(err error) *Expectation {
+	e.steps = append(e.steps, step{err: err})
+	return e
+}
+
+// Delay 最后一个响应的延迟，期间请求的 Context 结束时返回其错误，还没有响应时增加一个 200 响应
+func (e *Expectation) Delay(d time.Duration) *Expectation {
+	if len(e.steps) == 0 {
+		e.Reply(http.StatusOK, "")
+	}
+	e.steps[len(e.steps)-1].delay = d
+	return e
+}
+
+// Calls 已经匹配的次数
+func (e *Expectation) Calls() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.calls
+}
+
+func (e *Expectation) match(req *http.Request, body []byte) bool {
+	if e.times > 0 && e.calls >= e.times {
+		return false
+	}
+	if e.method != "" && e.method != "*" && e.method != req.Method {
+		return false
+	}
+	target := req.URL.Path
+	if !strings.HasPrefix(e.pattern, "/") {
+		u := *req.URL
+		u.RawQuery, u.Fragment = "", ""
+		target = u.String()
+	}
+	if ok, _ := path.Match(e.pattern, target); !ok {
+		return false
+	}
+	for _, m := range e.matchers {
+		if !m(req, body) {
+			return false
+		}
+	}
+	return true
+}
+
+// next 取得本次的响应，调用时持有锁
+func (e *Expectation) next() step {
+	e.calls++
+	if len(e.steps) == 0 {
+		return step{status: http.StatusOK, header: http.Header{}}
+	}
+	i := e.calls - 1
+	if i >= len(e.steps) {
+		i = len(e.steps) - 1
+	}
+	return e.steps[i]
+}
+
+type step struct {
+	status int
+	body   string
+	header http.Header
+	delay  time.Duration
+	err    error
+	fn     func(req *http.Request) (*http.Response, error)
+}
+
+func (s step) do(req *http.Request) (*http.Response, error) {
+	if s.delay > 0 {
+		timer := time.NewTimer(s.delay)
+		defer timer.Stop()
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+	switch {
+	case s.err != nil:
+		return nil, s.err
+	case s.fn != nil:
+		return s.fn(req)
+	}
+
+	header := s.header.Clone()
+	if header.Get("Content-Length") == "" {
+		header.Set("Content-Length", strconv.Itoa(len(s.body)))
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", s.status, http.StatusText(s.status)),
+		StatusCode:    s.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader([]byte(s.body))),
+		ContentLength: int64(len(s.body)),
+		Request:       req,
+	}, nil
+}
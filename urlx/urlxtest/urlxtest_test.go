@@ -0,0 +1,150 @@
+package urlxtest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cnk3x/go/urlx"
+)
+
+// recorder 记录 AssertExpectations 报告的错误
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestExpectations(t *testing.T) {
+	mt := New()
+	mt.Expect("GET", "/users/*").Header("Authorization", "Bearer x").
+		Reply(http.StatusOK, `{"id":1}`).ReplyHeader("Content-Type", "application/json")
+	mt.Expect("POST", "https://api.example.com/items?draft=1").Body(BodyJSON(`{"name": "a", "tags": ["x"]}`)).Once().
+		Reply(http.StatusCreated, "created")
+
+	var user struct{ ID int }
+	_, err := urlx.Default(nil).With(mt.Option()).Url("http://localhost/users/1").
+		HeaderWith(urlx.HeaderSet("Authorization", "Bearer x")).JSON(&user)
+	if err != nil || user.ID != 1 {
+		t.Fatalf("user = %+v, err = %v", user, err)
+	}
+
+	var status int
+	err = urlx.Default(nil).UseClient(mt.Client()).Url("https://api.example.com/items").Query("draft=1").
+		Method(http.MethodPost).SendJSON(`{"tags":["x"],"name":"a"}`).
+		Process(func(resp *http.Response, body io.ReadCloser) error {
+			status = resp.StatusCode
+			return body.Close()
+		})
+	if err != nil || status != http.StatusCreated {
+		t.Fatalf("status = %d, err = %v", status, err)
+	}
+	mt.AssertExpectations(t)
+
+	// 次数用完后不再匹配
+	_, err = urlx.Default(nil).UseClient(mt.Client()).Url("https://api.example.com/items?draft=1").
+		Method(http.MethodPost).SendJSON(`{"tags":["x"],"name":"a"}`).Bytes()
+	if !errors.Is(err, ErrUnexpected) {
+		t.Fatalf("err = %v, want ErrUnexpected", err)
+	}
+
+	rec := &recorder{}
+	mt.Expect("DELETE", "/never")
+	mt.AssertExpectations(rec)
+	if len(rec.errors) != 2 || !strings.Contains(rec.errors[0], "DELETE /never was not called") || !strings.Contains(rec.errors[1], "unexpected request POST") {
+		t.Fatalf("errors = %q", rec.errors)
+	}
+}
+
+func TestRespondReadsBody(t *testing.T) {
+	mt := New()
+	mt.Expect("POST", "/echo").Respond(func(req *http.Request) (*http.Response, error) {
+		data, err := io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(data)), Request: req}, nil
+	})
+
+	data, err := urlx.Default(nil).UseClient(mt.Client()).Url("http://localhost/echo").
+		Method(http.MethodPost).SendJSON(`{"a":1}`).Bytes()
+	if err != nil || string(data) != `{"a":1}` {
+		t.Fatalf("body = %q, err = %v", data, err)
+	}
+	mt.AssertExpectations(t)
+}
+
+func TestRetryAndLatency(t *testing.T) {
+	mt := New()
+	items := mt.Expect("", "/items").Fail(io.ErrUnexpectedEOF).Fail(io.ErrUnexpectedEOF).Reply(http.StatusOK, "ok")
+
+	data, err := urlx.Default(nil).UseClient(mt.Client()).TryAt(time.Millisecond, time.Millisecond).Url("http://x/items").Bytes()
+	if err != nil || string(data) != "ok" || items.Calls() != 3 {
+		t.Fatalf("data = %q, err = %v, calls = %d", data, err, items.Calls())
+	}
+
+	mt.Expect("GET", "/slow").Reply(http.StatusOK, "late").Delay(time.Second)
+	start := time.Now()
+	_, err = urlx.Default(nil).UseClient(mt.Client()).AttemptTimeout(20 * time.Millisecond).Url("http://x/slow").Bytes()
+	if !errors.Is(err, urlx.ErrTimeout) || time.Since(start) > 500*time.Millisecond {
+		t.Fatalf("err = %v after %s, want attempt timeout", err, time.Since(start))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err = urlx.Default(ctx).UseClient(mt.Client()).Url("http://x/slow").Bytes(); !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want canceled", err)
+	}
+	mt.AssertExpectations(t)
+}
+
+func TestConcurrent(t *testing.T) {
+	mt := New()
+	e := mt.Expect("GET", "/n").Respond(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(req.URL.Query().Get("i"))), Request: req}, nil
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			data, err := urlx.Default(nil).UseClient(mt.Client()).Url(fmt.Sprintf("http://x/n?i=%d", i)).Bytes()
+			if err != nil || string(data) != fmt.Sprint(i) {
+				t.Errorf("%d: %q %v", i, data, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if e.Calls() != 20 {
+		t.Fatalf("calls = %d", e.Calls())
+	}
+}
+
+func TestBodyMatchers(t *testing.T) {
+	body := []byte(`{"a": 1, "b": [true, null]}`)
+	for name, ok := range map[string]bool{
+		"equals":   BodyEquals(string(body))(body),
+		"contains": BodyContains(`"b"`)(body),
+		"regexp":   BodyRegexp(`"a":\s*\d`)(body),
+		"json":     BodyJSON(`{"b":[true,null],"a":1}`)(body),
+		"json!":    !BodyJSON(`{"a":2}`)(body),
+		"notjson":  !BodyJSON(`{}`)([]byte("x")),
+	} {
+		if !ok {
+			t.Errorf("%s failed", name)
+		}
+	}
+}
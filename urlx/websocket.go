@@ -0,0 +1,164 @@
+package urlx
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const webSocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// DialWebSocket 以请求的设置握手升级为 WebSocket，链接可以是 ws://、wss:// 或 http(s)://
+//
+// 请求头、Cookie、代理和 TLS 设置、中间件照常生效，握手的 Set-Cookie 会写入 Cookie 容器。
+// 握手响应不是 101 时返回 *StatusError，MaxBodySize 限制每条消息的大小，未设置时每帧最大 32MB，Timeout 限制握手的时间
+func (c *Request) DialWebSocket(protocols ...string) (*WebSocket, error) {
+	r, err := c.applied()
+	if err != nil {
+		return nil, err
+	}
+	r.method = http.MethodGet
+	r.buildBody = func() (contentType string, body io.Reader, err error) { return "", nil, nil }
+
+	requestUrl := r.RequestURL()
+	if lower := strings.ToLower(requestUrl); strings.HasPrefix(lower, "ws://") {
+		requestUrl = "http://" + requestUrl[5:]
+	} else if strings.HasPrefix(lower, "wss://") {
+		requestUrl = "https://" + requestUrl[6:]
+	}
+
+	ctx := r.ctx
+	if r.timeouts.overall > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.timeouts.overall)
+		defer cancel()
+	}
+	req, err := r.build(ctx, requestUrl, 0)
+	if err != nil {
+		return nil, err.(*buildError).err
+	}
+
+	nonce := make([]byte, 16)
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(nonce)
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	if len(protocols) > 0 {
+		req.Header.Set("Sec-WebSocket-Protocol", strings.Join(protocols, ", "))
+	}
+
+	// 客户端的 Timeout 会包裹响应内容，升级后的连接不能再写入
+	client := *r.redirect.client(r.client, r.Header())
+	client.Timeout = 0
+	resp, err := Chain(&client, r.middlewares...).Do(req)
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded && r.timeouts.overall > 0 {
+			return nil, &TimeoutError{Kind: TimeoutOverall, Duration: r.timeouts.overall, Err: err}
+		}
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		return nil, &StatusError{StatusCode: resp.StatusCode, Status: resp.Status, Body: data}
+	}
+
+	conn, ok := resp.Body.(io.ReadWriteCloser)
+	if !ok {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: response body %T is not writable, a middleware may have replaced it", ErrWebSocket, resp.Body)
+	}
+	sum := sha1.Sum([]byte(key + webSocketGUID))
+	if !strings.EqualFold(resp.Header.Get("Upgrade"), "websocket") || resp.Header.Get("Sec-WebSocket-Accept") != base64.StdEncoding.EncodeToString(sum[:]) {
+		conn.Close()
+		return nil, fmt.Errorf("%w: invalid handshake response", ErrWebSocket)
+	}
+	return newWebSocket(conn, resp, r.limits.maxSize), nil
+}
+
+// KeepAlive 每隔 interval 发送 ping，超过 interval+timeout 没有收到任何帧时关闭连接，
+// ReadMessage 随之返回 TimeoutIdle 的 *TimeoutError。需要有协程在调用 ReadMessage 才能收到 pong
+func (ws *WebSocket) KeepAlive(interval, timeout time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ws.closed:
+				return
+			case now := <-ticker.C:
+				if now.Sub(time.Unix(0, atomic.LoadInt64(&ws.lastRead))) > interval+timeout {
+					atomic.StoreInt64(&ws.expired, int64(interval+timeout))
+					ws.shutdown()
+					return
+				}
+				if ws.Ping(nil) != nil {
+					return
+				}
+			}
+		}
+	}()
+}
+
+// ReconnectWebSocket 保持 WebSocket 连接，每次连接成功后以 fn 处理，fn 返回 nil 时结束。
+// fn 返回错误或连接失败时依次按 backoff 等待后重连，最后一个时间重复使用，连接成功后从头开始。
+// ctx 取消时关闭连接并返回，握手返回 4xx（429 除外）时不再重连，返回 *StatusError
+func (c *Request) ReconnectWebSocket(ctx context.Context, backoff []time.Duration, fn func(ws *WebSocket) error, protocols ...string) error {
+	if len(backoff) == 0 {
+		backoff = []time.Duration{time.Second}
+	}
+
+	for retries := 0; ; retries++ {
+		ws, err := c.WithContext(ctx).DialWebSocket(protocols...)
+		if err == nil {
+			retries = 0
+			stop := make(chan struct{})
+			go func() {
+				select {
+				case <-ctx.Done():
+					_ = ws.CloseWith(CloseGoingAway, "")
+				case <-stop:
+				}
+			}()
+			err = fn(ws)
+			close(stop)
+			_ = ws.Close()
+			if err == nil {
+				return nil
+			}
+		} else {
+			var se *StatusError
+			if errors.As(err, &se) && se.StatusCode >= 400 && se.StatusCode < 500 && se.StatusCode != http.StatusTooManyRequests {
+				return err
+			}
+		}
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		wait := backoff[len(backoff)-1]
+		if retries < len(backoff) {
+			wait = backoff[retries]
+		}
+		log.Printf("websocket 断开: %v, %s后重连", err, wait)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
@@ -0,0 +1,310 @@
+package urlx
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	ErrWebSocket       = errors.New("websocket: protocol error")
+	ErrWebSocketClosed = errors.New("websocket: closed")
+)
+
+// 消息类型
+const (
+	TextMessage   = 1
+	BinaryMessage = 2
+)
+
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xa
+)
+
+// webSocketFrameLimit 没有设置 MaxBodySize 时一帧的最大字节数，防止按对方声明的长度分配内存
+const webSocketFrameLimit = 32 << 20
+
+// 常用的关闭码
+const (
+	CloseNormal        = 1000
+	CloseGoingAway     = 1001
+	CloseProtocolError = 1002
+	CloseNoStatus      = 1005
+	CloseAbnormal      = 1006
+	CloseTooLarge      = 1009
+)
+
+// CloseError 收到对方的关闭帧，errors.Is(err, ErrWebSocketClosed) 为真
+type CloseError struct {
+	Code   int
+	Reason string
+}
+
+func (e *CloseError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("%s: %d", ErrWebSocketClosed, e.Code)
+	}
+	return fmt.Sprintf("%s: %d %s", ErrWebSocketClosed, e.Code, e.Reason)
+}
+
+func (e *CloseError) Unwrap() error {
+	return ErrWebSocketClosed
+}
+
+// WebSocket 客户端连接，ReadMessage 只能在一个协程中调用，写入可以并发
+type WebSocket struct {
+	// 原子操作，放在最前保证 32 位平台上 64 位对齐
+	lastRead int64 // 最后收到帧的时间，UnixNano
+	expired  int64 // KeepAlive 超时关闭时的时长
+
+	conn    io.ReadWriteCloser
+	br      *bufio.Reader
+	resp    *http.Response
+	maxSize int64
+
+	wmu       sync.Mutex
+	closeSent bool
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+func newWebSocket(conn io.ReadWriteCloser, resp *http.Response, maxSize int64) *WebSocket {
+	return &WebSocket{
+		conn:     conn,
+		br:       bufio.NewReader(conn),
+		resp:     resp,
+		maxSize:  maxSize,
+		closed:   make(chan struct{}),
+		lastRead: time.Now().UnixNano(),
+	}
+}
+
+// Response 握手的响应
+func (ws *WebSocket) Response() *http.Response {
+	return ws.resp
+}
+
+// Subprotocol 服务端选择的子协议
+func (ws *WebSocket) Subprotocol() string {
+	return ws.resp.Header.Get("Sec-WebSocket-Protocol")
+}
+
+// Done 连接关闭后关闭的通道
+func (ws *WebSocket) Done() <-chan struct{} {
+	return ws.closed
+}
+
+// ReadMessage 读取一条完整的消息，自动回复 ping，收到关闭帧时回复并返回 *CloseError
+func (ws *WebSocket) ReadMessage() (messageType int, data []byte, err error) {
+	for {
+		fin, op, payload, err := ws.readFrame()
+		if err != nil {
+			if errors.Is(err, ErrWebSocket) || errors.Is(err, ErrBodyTooLarge) {
+				code := CloseProtocolError
+				if errors.Is(err, ErrBodyTooLarge) {
+					code = CloseTooLarge
+				}
+				_ = ws.CloseWith(code, "")
+			} else {
+				ws.shutdown()
+			}
+			if d := atomic.LoadInt64(&ws.expired); d > 0 {
+				err = &TimeoutError{Kind: TimeoutIdle, Duration: time.Duration(d), Err: err}
+			}
+			return 0, nil, err
+		}
+		atomic.StoreInt64(&ws.lastRead, time.Now().UnixNano())
+
+		switch op {
+		case opPing:
+			if err = ws.writeFrame(opPong, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case opPong:
+			continue
+		case opClose:
+			ce := &CloseError{Code: CloseNoStatus}
+			if len(payload) >= 2 {
+				ce.Code, ce.Reason = int(binary.BigEndian.Uint16(payload)), string(payload[2:])
+			}
+			reply := ce.Code
+			if reply == CloseNoStatus {
+				reply = CloseNormal
+			}
+			_ = ws.CloseWith(reply, "")
+			return 0, nil, ce
+		case opContinuation:
+			if messageType == 0 {
+				return 0, nil, ws.protocolError("unexpected continuation frame")
+			}
+			data = append(data, payload...)
+		case opText, opBinary:
+			if messageType != 0 {
+				return 0, nil, ws.protocolError("expected continuation frame")
+			}
+			messageType, data = int(op), payload
+		default:
+			return 0, nil, ws.protocolError(fmt.Sprintf("unknown opcode %d", op))
+		}
+
+		if ws.maxSize > 0 && int64(len(data)) > ws.maxSize {
+			_ = ws.CloseWith(CloseTooLarge, "")
+			return 0, nil, &BodyTooLargeError{Limit: ws.maxSize}
+		}
+		if fin {
+			if messageType == TextMessage && !utf8.Valid(data) {
+				return 0, nil, ws.protocolError("invalid utf-8 text")
+			}
+			return messageType, data, nil
+		}
+	}
+}
+
+// WriteMessage 发送一条消息
+func (ws *WebSocket) WriteMessage(messageType int, data []byte) error {
+	if messageType != TextMessage && messageType != BinaryMessage {
+		return fmt.Errorf("%w: invalid message type %d", ErrWebSocket, messageType)
+	}
+	return ws.writeFrame(byte(messageType), data)
+}
+
+// Ping 发送 ping，对方的 pong 在 ReadMessage 中处理
+func (ws *WebSocket) Ping(data []byte) error {
+	return ws.writeFrame(opPing, data)
+}
+
+// Close 以 1000 正常关闭
+func (ws *WebSocket) Close() error {
+	return ws.CloseWith(CloseNormal, "")
+}
+
+// CloseWith 发送关闭帧后关闭连接
+func (ws *WebSocket) CloseWith(code int, reason string) error {
+	payload := make([]byte, 2, 2+len(reason))
+	binary.BigEndian.PutUint16(payload, uint16(code))
+	payload = append(payload, reason...)
+	err := ws.writeFrame(opClose, payload)
+	ws.shutdown()
+	if errors.Is(err, ErrWebSocketClosed) {
+		return nil
+	}
+	return err
+}
+
+func (ws *WebSocket) shutdown() {
+	ws.closeOnce.Do(func() {
+		close(ws.closed)
+		_ = ws.conn.Close()
+	})
+}
+
+func (ws *WebSocket) protocolError(msg string) error {
+	_ = ws.CloseWith(CloseProtocolError, "")
+	return fmt.Errorf("%w: %s", ErrWebSocket, msg)
+}
+
+// readFrame 读取一帧，服务端的帧不能有掩码
+func (ws *WebSocket) readFrame() (fin bool, op byte, payload []byte, err error) {
+	var head [8]byte
+	if _, err = io.ReadFull(ws.br, head[:2]); err != nil {
+		return
+	}
+	fin, op = head[0]&0x80 != 0, head[0]&0x0f
+	if head[0]&0x70 != 0 {
+		return false, 0, nil, fmt.Errorf("%w: reserved bits set", ErrWebSocket)
+	}
+	if head[1]&0x80 != 0 {
+		return false, 0, nil, fmt.Errorf("%w: masked server frame", ErrWebSocket)
+	}
+
+	n := uint64(head[1] & 0x7f)
+	switch n {
+	case 126:
+		if _, err = io.ReadFull(ws.br, head[:2]); err != nil {
+			return
+		}
+		n = uint64(binary.BigEndian.Uint16(head[:2]))
+	case 127:
+		if _, err = io.ReadFull(ws.br, head[:8]); err != nil {
+			return
+		}
+		n = binary.BigEndian.Uint64(head[:8])
+		if n>>63 != 0 {
+			return false, 0, nil, fmt.Errorf("%w: invalid payload length", ErrWebSocket)
+		}
+	}
+	if op >= opClose && (n > 125 || !fin) {
+		return false, 0, nil, fmt.Errorf("%w: invalid control frame", ErrWebSocket)
+	}
+	if n > math.MaxInt {
+		return false, 0, nil, fmt.Errorf("%w: payload length %d overflows int", ErrWebSocket, n)
+	}
+	limit := ws.maxSize
+	if limit <= 0 {
+		limit = webSocketFrameLimit
+	}
+	if n > uint64(limit) {
+		return false, 0, nil, &BodyTooLargeError{Limit: limit}
+	}
+
+	payload = make([]byte, n)
+	_, err = io.ReadFull(ws.br, payload)
+	return
+}
+
+// writeFrame 以一帧发送，客户端的帧必须有掩码
+func (ws *WebSocket) writeFrame(op byte, payload []byte) error {
+	ws.wmu.Lock()
+	defer ws.wmu.Unlock()
+	select {
+	case <-ws.closed:
+		return ErrWebSocketClosed
+	default:
+	}
+	if ws.closeSent {
+		return ErrWebSocketClosed
+	}
+	if op == opClose {
+		ws.closeSent = true
+	}
+
+	frame := make([]byte, 0, 14+len(payload))
+	frame = append(frame, 0x80|op)
+	switch n := len(payload); {
+	case n <= 125:
+		frame = append(frame, 0x80|byte(n))
+	case n <= 0xffff:
+		frame = append(frame, 0x80|126, byte(n>>8), byte(n))
+	default:
+		var size [8]byte
+		binary.BigEndian.PutUint64(size[:], uint64(n))
+		frame = append(append(frame, 0x80|127), size[:]...)
+	}
+
+	var mask [4]byte
+	if _, err := rand.Read(mask[:]); err != nil {
+		return err
+	}
+	frame = append(frame, mask[:]...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+
+	_, err := ws.conn.Write(frame)
+	return err
+}
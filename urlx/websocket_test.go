@@ -0,0 +1,324 @@
+package urlx
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// wsServerConn 测试用的服务端连接
+type wsServerConn struct {
+	conn net.Conn
+	br   *bufio.Reader
+}
+
+func (c *wsServerConn) read() (op byte, payload []byte, err error) {
+	var head [8]byte
+	if _, err = io.ReadFull(c.br, head[:2]); err != nil {
+		return
+	}
+	op = head[0] & 0x0f
+	n := uint64(head[1] & 0x7f)
+	switch n {
+	case 126:
+		_, err = io.ReadFull(c.br, head[:2])
+		n = uint64(binary.BigEndian.Uint16(head[:2]))
+	case 127:
+		_, err = io.ReadFull(c.br, head[:8])
+		n = binary.BigEndian.Uint64(head[:8])
+	}
+	var mask [4]byte
+	if _, err = io.ReadFull(c.br, mask[:]); err != nil {
+		return
+	}
+	payload = make([]byte, n)
+	if _, err = io.ReadFull(c.br, payload); err != nil {
+		return
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return
+}
+
+func (c *wsServerConn) write(fin bool, op byte, payload []byte) {
+	b0 := op
+	if fin {
+		b0 |= 0x80
+	}
+	frame := []byte{b0}
+	if n := len(payload); n <= 125 {
+		frame = append(frame, byte(n))
+	} else {
+		frame = append(frame, 126, byte(n>>8), byte(n))
+	}
+	_, _ = c.conn.Write(append(frame, payload...))
+}
+
+func (c *wsServerConn) close(code int, reason string) {
+	payload := []byte{byte(code >> 8), byte(code)}
+	c.write(true, opClose, append(payload, reason...))
+}
+
+func wsHandler(serve func(r *http.Request, c *wsServerConn)) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Upgrade") != "websocket" || r.Header.Get("Sec-WebSocket-Version") != "13" {
+			http.Error(rw, "not websocket", http.StatusBadRequest)
+			return
+		}
+		if c, err := r.Cookie("session"); err != nil || c.Value != "ok" {
+			http.Error(rw, "login first", http.StatusUnauthorized)
+			return
+		}
+		conn, brw, err := rw.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		sum := sha1.Sum([]byte(r.Header.Get("Sec-WebSocket-Key") + webSocketGUID))
+		_, _ = brw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+			"Sec-WebSocket-Protocol: chat\r\nSet-Cookie: ws=1; Path=/\r\n" +
+			"Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(sum[:]) + "\r\n\r\n")
+		_ = brw.Flush()
+		serve(r, &wsServerConn{conn: conn, br: brw.Reader})
+	}
+}
+
+func wsLogin(rw http.ResponseWriter, r *http.Request) {
+	http.SetCookie(rw, &http.Cookie{Name: "session", Value: "ok", Path: "/"})
+}
+
+func TestWebSocket(t *testing.T) {
+	serverErr := make(chan string, 1)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", wsLogin)
+	mux.Handle("/ws", wsHandler(func(r *http.Request, c *wsServerConn) {
+		c.write(true, opPing, []byte("p"))
+		op, payload, err := c.read()
+		if err != nil || op != opText {
+			serverErr <- "want text"
+			return
+		}
+		// 客户端在下一次 ReadMessage 时回复 pong
+		if op, pong, err := c.read(); err != nil || op != opPong || string(pong) != "p" {
+			serverErr <- "want pong"
+			return
+		}
+		c.write(true, opText, payload)
+		c.write(false, opBinary, []byte{1, 2})
+		c.write(true, opContinuation, []byte{3})
+		c.close(4000, "bye")
+		if op, payload, _ = c.read(); op != opClose || binary.BigEndian.Uint16(payload) != 4000 {
+			serverErr <- "want close reply"
+			return
+		}
+		serverErr <- ""
+	}))
+	addr, closer := mockHTTPServer(mux)
+	defer closer()
+
+	req := Default(nil).With(CookieEnabled())
+	if _, err := req.Clone().Url(addr + "/login").Bytes(); err != nil {
+		t.Fatal(err)
+	}
+	// Timeout 只限制握手，连接之后不受影响
+	ws, err := req.Clone().Url("ws" + addr[len("http"):] + "/ws").Timeout(time.Second).DialWebSocket("chat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+	eq(t, [][2]any{{ws.Subprotocol(), "chat"}})
+
+	if err = ws.WriteMessage(TextMessage, []byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	typ, data, err := ws.ReadMessage()
+	eq(t, [][2]any{{err, nil}, {typ, TextMessage}, {string(data), "hello"}})
+	typ, data, err = ws.ReadMessage()
+	eq(t, [][2]any{{err, nil}, {typ, BinaryMessage}, {string(data), "\x01\x02\x03"}})
+
+	_, _, err = ws.ReadMessage()
+	var ce *CloseError
+	if !errors.As(err, &ce) || ce.Code != 4000 || ce.Reason != "bye" {
+		t.Fatalf("err = %v, want close 4000", err)
+	}
+	if msg := <-serverErr; msg != "" {
+		t.Fatal(msg)
+	}
+	if err = ws.WriteMessage(TextMessage, nil); !errors.Is(err, ErrWebSocketClosed) {
+		t.Fatalf("write after close: %v", err)
+	}
+
+	// 没有登录的 Cookie 时握手失败，不再重连
+	var se *StatusError
+	_, err = Default(nil).Url(addr + "/ws").DialWebSocket()
+	if !errors.As(err, &se) || se.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("err = %v, want 401", err)
+	}
+	err = Default(nil).Url(addr+"/ws").ReconnectWebSocket(context.Background(), nil, func(ws *WebSocket) error { return nil })
+	if !errors.As(err, &se) {
+		t.Fatalf("reconnect err = %v, want 401", err)
+	}
+}
+
+func TestWebSocketReconnect(t *testing.T) {
+	var conns int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", wsLogin)
+	mux.Handle("/ws", wsHandler(func(r *http.Request, c *wsServerConn) {
+		n := atomic.AddInt32(&conns, 1)
+		if n == 1 {
+			return // 直接断开
+		}
+		c.write(true, opText, []byte("ready"))
+		_, _, _ = c.read()
+	}))
+	addr, closer := mockHTTPServer(mux)
+	defer closer()
+
+	req := Default(nil).With(CookieEnabled())
+	if _, err := req.Clone().Url(addr + "/login").Bytes(); err != nil {
+		t.Fatal(err)
+	}
+	var got string
+	err := req.Url(addr+"/ws").ReconnectWebSocket(context.Background(), []time.Duration{10 * time.Millisecond}, func(ws *WebSocket) error {
+		_, data, err := ws.ReadMessage()
+		if err != nil {
+			return err
+		}
+		got = string(data)
+		return nil
+	})
+	eq(t, [][2]any{{err, nil}, {got, "ready"}, {atomic.LoadInt32(&conns), int32(2)}})
+
+	// ctx 取消时关闭连接并返回
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err = req.Url(addr+"/ws").ReconnectWebSocket(ctx, []time.Duration{10 * time.Millisecond}, func(ws *WebSocket) error {
+		for {
+			if _, _, err := ws.ReadMessage(); err != nil {
+				return err
+			}
+		}
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want deadline exceeded", err)
+	}
+}
+
+func TestWebSocketKeepAlive(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", wsLogin)
+	mux.Handle("/pong", wsHandler(func(r *http.Request, c *wsServerConn) {
+		done := time.After(150 * time.Millisecond)
+		go func() {
+			<-done
+			c.write(true, opText, []byte("done"))
+		}()
+		for {
+			op, payload, err := c.read()
+			if err != nil || op == opClose {
+				return
+			}
+			if op == opPing {
+				c.write(true, opPong, payload)
+			}
+		}
+	}))
+	mux.Handle("/silent", wsHandler(func(r *http.Request, c *wsServerConn) {
+		for {
+			if _, _, err := c.read(); err != nil {
+				return
+			}
+		}
+	}))
+	addr, closer := mockHTTPServer(mux)
+	defer closer()
+
+	req := Default(nil).With(CookieEnabled())
+	if _, err := req.Clone().Url(addr + "/login").Bytes(); err != nil {
+		t.Fatal(err)
+	}
+
+	ws, err := req.Clone().Url(addr + "/pong").DialWebSocket()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ws.KeepAlive(20*time.Millisecond, 20*time.Millisecond)
+	_, data, err := ws.ReadMessage()
+	eq(t, [][2]any{{err, nil}, {string(data), "done"}})
+	_ = ws.Close()
+
+	ws, err = req.Clone().Url(addr + "/silent").DialWebSocket()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ws.KeepAlive(20*time.Millisecond, 20*time.Millisecond)
+	_, _, err = ws.ReadMessage()
+	var te *TimeoutError
+	if !errors.As(err, &te) || te.Kind != TimeoutIdle {
+		t.Fatalf("err = %v, want idle timeout", err)
+	}
+}
+
+func TestWebSocketHostileLength(t *testing.T) {
+	closes := make(chan uint16, 1)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", wsLogin)
+	mux.Handle("/ws", wsHandler(func(r *http.Request, c *wsServerConn) {
+		// 只发送帧头，声明的长度由 Query 参数 n 给出
+		var n uint64
+		_, _ = fmt.Sscan(r.URL.Query().Get("n"), &n)
+		head := []byte{0x80 | opBinary, 127, 0, 0, 0, 0, 0, 0, 0, 0}
+		binary.BigEndian.PutUint64(head[2:], n)
+		_, _ = c.conn.Write(head)
+		op, payload, err := c.read()
+		if err != nil || op != opClose || len(payload) < 2 {
+			closes <- 0
+			return
+		}
+		closes <- binary.BigEndian.Uint16(payload)
+	}))
+	addr, closer := mockHTTPServer(mux)
+	defer closer()
+
+	req := Default(nil).With(CookieEnabled())
+	if _, err := req.Clone().Url(addr + "/login").Bytes(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []struct {
+		n       uint64
+		maxSize int64
+		err     error
+		code    uint16
+		limit   int64
+	}{
+		{1 << 63, 0, ErrWebSocket, CloseProtocolError, 0},
+		{1<<63 - 1, 0, ErrBodyTooLarge, CloseTooLarge, webSocketFrameLimit},
+		{webSocketFrameLimit + 1, 0, ErrBodyTooLarge, CloseTooLarge, webSocketFrameLimit},
+		{17, 16, ErrBodyTooLarge, CloseTooLarge, 16},
+	} {
+		ws, err := req.Clone().Url(fmt.Sprintf("%s/ws?n=%d", addr, c.n)).MaxBodySize(c.maxSize).DialWebSocket()
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _, err = ws.ReadMessage()
+		var tooLarge *BodyTooLargeError
+		if errors.As(err, &tooLarge) && tooLarge.Limit != c.limit {
+			t.Errorf("n=%d: limit %d, want %d", c.n, tooLarge.Limit, c.limit)
+		}
+		eq(t, [][2]any{{errors.Is(err, c.err), true}, {<-closes, c.code}})
+		_ = ws.Close()
+	}
+}
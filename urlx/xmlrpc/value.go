@@ -0,0 +1,460 @@
+package xmlrpc
+
+import (
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type methodCall struct {
+	XMLName xml.Name `xml:"methodCall"`
+	Method  string   `xml:"methodName"`
+	Params  []value  `xml:"params>param>value"`
+}
+
+type methodResponse struct {
+	XMLName xml.Name `xml:"methodResponse"`
+	Params  []value  `xml:"params>param>value"`
+	Fault   *value   `xml:"fault>value"`
+}
+
+// value 一个 <value>，解码后为 int64、bool、string、float64、time.Time、[]byte、[]any、map[string]any 或 nil
+type value struct {
+	v any
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// dateTime.iso8601 常见的几种写法
+var timeLayouts = []string{"20060102T15:04:05", "20060102T15:04:05Z07:00", "2006-01-02T15:04:05", time.RFC3339}
+
+func (v value) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return encodeValue(e, reflect.ValueOf(v.v))
+}
+
+func (v *value) UnmarshalXML(d *xml.Decoder, start xml.StartElement) (err error) {
+	v.v, err = decodeValue(d)
+	return
+}
+
+func encodeValue(e *xml.Encoder, rv reflect.Value) error {
+	start := xml.StartElement{Name: xml.Name{Local: "value"}}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	if err := encodeInner(e, rv); err != nil {
+		return err
+	}
+	return e.EncodeToken(start.End())
+}
+
+func encodeInner(e *xml.Encoder, rv reflect.Value) error {
+	element := func(name, text string) error {
+		return e.EncodeElement(text, xml.StartElement{Name: xml.Name{Local: name}})
+	}
+	wrap := func(names []string, inner func() error) error {
+		for _, name := range names {
+			if err := e.EncodeToken(xml.StartElement{Name: xml.Name{Local: name}}); err != nil {
+				return err
+			}
+		}
+		if err := inner(); err != nil {
+			return err
+		}
+		for i := len(names) - 1; i >= 0; i-- {
+			if err := e.EncodeToken(xml.EndElement{Name: xml.Name{Local: names[i]}}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if !rv.IsValid() {
+		return wrap([]string{"nil"}, func() error { return nil })
+	}
+	if rv.CanInterface() {
+		if v, ok := rv.Interface().(value); ok {
+			return encodeInner(e, reflect.ValueOf(v.v))
+		}
+	}
+	if rv.Type() == timeType {
+		return element("dateTime.iso8601", rv.Interface().(time.Time).Format(timeLayouts[0]))
+	}
+
+	switch rv.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		if rv.IsNil() {
+			return encodeInner(e, reflect.Value{})
+		}
+		return encodeInner(e, rv.Elem())
+	case reflect.Bool:
+		if rv.Bool() {
+			return element("boolean", "1")
+		}
+		return element("boolean", "0")
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n := rv.Int(); n < math.MinInt32 || n > math.MaxInt32 {
+			return element("i8", strconv.FormatInt(n, 10))
+		}
+		return element("int", strconv.FormatInt(rv.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if n := rv.Uint(); n > math.MaxInt32 {
+			return element("i8", strconv.FormatUint(n, 10))
+		}
+		return element("int", strconv.FormatUint(rv.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		return element("double", strconv.FormatFloat(rv.Float(), 'f', -1, 64))
+	case reflect.String:
+		return element("string", rv.String())
+	case reflect.Slice, reflect.Array:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			data := make([]byte, rv.Len())
+			reflect.Copy(reflect.ValueOf(data), rv)
+			return element("base64", base64.StdEncoding.EncodeToString(data))
+		}
+		return wrap([]string{"array", "data"}, func() error {
+			for i := 0; i < rv.Len(); i++ {
+				if err := encodeValue(e, rv.Index(i)); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			break
+		}
+		keys := rv.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		return wrap([]string{"struct"}, func() error {
+			for _, k := range keys {
+				if err := encodeMember(e, k.String(), rv.MapIndex(k)); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	case reflect.Struct:
+		return wrap([]string{"struct"}, func() error {
+			for _, f := range structFields(rv.Type()) {
+				fv := rv.Field(f.index)
+				if f.omitEmpty && fv.IsZero() {
+					continue
+				}
+				if err := encodeMember(e, f.name, fv); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+	return fmt.Errorf("xmlrpc: unsupported type %s", rv.Type())
+}
+
+func encodeMember(e *xml.Encoder, name string, rv reflect.Value) error {
+	member := xml.StartElement{Name: xml.Name{Local: "member"}}
+	if err := e.EncodeToken(member); err != nil {
+		return err
+	}
+	if err := e.EncodeElement(name, xml.StartElement{Name: xml.Name{Local: "name"}}); err != nil {
+		return err
+	}
+	if err := encodeValue(e, rv); err != nil {
+		return err
+	}
+	return e.EncodeToken(member.End())
+}
+
+// decodeValue 读取 <value> 的内容直到 </value>，没有类型元素时为字符串
+func decodeValue(d *xml.Decoder) (result any, err error) {
+	var text []byte
+	typed := false
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.CharData:
+			text = append(text, t...)
+		case xml.StartElement:
+			if typed {
+				return nil, fmt.Errorf("unexpected <%s> in value", t.Name.Local)
+			}
+			typed = true
+			if result, err = decodeTyped(d, t); err != nil {
+				return nil, err
+			}
+		case xml.EndElement:
+			if !typed {
+				return string(text), nil
+			}
+			return result, nil
+		}
+	}
+}
+
+func decodeTyped(d *xml.Decoder, start xml.StartElement) (any, error) {
+	switch start.Name.Local {
+	case "array":
+		return decodeArray(d)
+	case "struct":
+		return decodeStruct(d)
+	case "nil":
+		return nil, d.Skip()
+	}
+
+	var s string
+	if err := d.DecodeElement(&s, &start); err != nil {
+		return nil, err
+	}
+	switch start.Name.Local {
+	case "string":
+		return s, nil
+	case "int", "i4", "i8":
+		return strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	case "double":
+		return strconv.ParseFloat(strings.TrimSpace(s), 64)
+	case "boolean":
+		switch strings.TrimSpace(s) {
+		case "1", "true":
+			return true, nil
+		case "0", "false":
+			return false, nil
+		}
+		return nil, fmt.Errorf("invalid boolean %q", s)
+	case "dateTime.iso8601":
+		s = strings.TrimSpace(s)
+		for _, layout := range timeLayouts {
+			if t, err := time.Parse(layout, s); err == nil {
+				return t, nil
+			}
+		}
+		return nil, fmt.Errorf("invalid dateTime.iso8601 %q", s)
+	case "base64":
+		return base64.StdEncoding.DecodeString(strings.Join(strings.Fields(s), ""))
+	}
+	return nil, fmt.Errorf("unknown type <%s>", start.Name.Local)
+}
+
+func decodeArray(d *xml.Decoder) ([]any, error) {
+	arr := []any{}
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "data":
+			case "value":
+				v, err := decodeValue(d)
+				if err != nil {
+					return nil, err
+				}
+				arr = append(arr, v)
+			default:
+				if err = d.Skip(); err != nil {
+					return nil, err
+				}
+			}
+		case xml.EndElement:
+			if t.Name.Local == "array" {
+				return arr, nil
+			}
+		}
+	}
+}
+
+func decodeStruct(d *xml.Decoder) (map[string]any, error) {
+	m := map[string]any{}
+	var name string
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "member":
+				name = ""
+			case "name":
+				if err = d.DecodeElement(&name, &t); err != nil {
+					return nil, err
+				}
+			case "value":
+				if m[name], err = decodeValue(d); err != nil {
+					return nil, err
+				}
+			default:
+				if err = d.Skip(); err != nil {
+					return nil, err
+				}
+			}
+		case xml.EndElement:
+			if t.Name.Local == "struct" {
+				return m, nil
+			}
+		}
+	}
+}
+
+// Unmarshal 将解码后的值赋给 out，out 为指针
+func Unmarshal(v any, out any) error {
+	rv := reflect.ValueOf(out)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("xmlrpc: unmarshal to non-pointer %T", out)
+	}
+	return assign(rv.Elem(), v)
+}
+
+func assign(dst reflect.Value, src any) error {
+	if src == nil {
+		dst.Set(reflect.Zero(dst.Type()))
+		return nil
+	}
+	if dst.Kind() == reflect.Interface && dst.NumMethod() == 0 {
+		dst.Set(reflect.ValueOf(src))
+		return nil
+	}
+	if dst.Kind() == reflect.Ptr {
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		return assign(dst.Elem(), src)
+	}
+
+	switch s := src.(type) {
+	case int64:
+		switch dst.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if !dst.OverflowInt(s) {
+				dst.SetInt(s)
+				return nil
+			}
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if s >= 0 && !dst.OverflowUint(uint64(s)) {
+				dst.SetUint(uint64(s))
+				return nil
+			}
+		case reflect.Float32, reflect.Float64:
+			dst.SetFloat(float64(s))
+			return nil
+		}
+	case float64:
+		if k := dst.Kind(); k == reflect.Float32 || k == reflect.Float64 {
+			dst.SetFloat(s)
+			return nil
+		}
+	case bool:
+		if dst.Kind() == reflect.Bool {
+			dst.SetBool(s)
+			return nil
+		}
+	case string:
+		if dst.Kind() == reflect.String {
+			dst.SetString(s)
+			return nil
+		}
+	case time.Time:
+		if dst.Type() == timeType {
+			dst.Set(reflect.ValueOf(s))
+			return nil
+		}
+	case []byte:
+		switch {
+		case dst.Kind() == reflect.String:
+			dst.SetString(string(s))
+			return nil
+		case dst.Kind() == reflect.Slice && dst.Type().Elem().Kind() == reflect.Uint8:
+			dst.SetBytes(append([]byte{}, s...))
+			return nil
+		}
+	case []any:
+		switch dst.Kind() {
+		case reflect.Slice:
+			dst.Set(reflect.MakeSlice(dst.Type(), len(s), len(s)))
+			fallthrough
+		case reflect.Array:
+			for i := 0; i < len(s) && i < dst.Len(); i++ {
+				if err := assign(dst.Index(i), s[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	case map[string]any:
+		switch dst.Kind() {
+		case reflect.Struct:
+			for _, f := range structFields(dst.Type()) {
+				v, ok := s[f.name]
+				if !ok {
+					for k, x := range s {
+						if strings.EqualFold(k, f.name) {
+							v, ok = x, true
+							break
+						}
+					}
+				}
+				if ok {
+					if err := assign(dst.Field(f.index), v); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		case reflect.Map:
+			if dst.Type().Key().Kind() != reflect.String {
+				break
+			}
+			if dst.IsNil() {
+				dst.Set(reflect.MakeMapWithSize(dst.Type(), len(s)))
+			}
+			for k, x := range s {
+				ev := reflect.New(dst.Type().Elem()).Elem()
+				if err := assign(ev, x); err != nil {
+					return err
+				}
+				dst.SetMapIndex(reflect.ValueOf(k).Convert(dst.Type().Key()), ev)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: cannot assign %T to %s", ErrResponse, src, dst.Type())
+}
+
+type field struct {
+	index     int
+	name      string
+	omitEmpty bool
+}
+
+// structFields 结构体的导出字段，名称取 xmlrpc 标签，标签为 - 时忽略
+func structFields(t reflect.Type) (fields []field) {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			continue
+		}
+		name, opts := sf.Tag.Get("xmlrpc"), ""
+		if i := strings.IndexByte(name, ','); i >= 0 {
+			name, opts = name[:i], name[i+1:]
+		}
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		fields = append(fields, field{index: i, name: name, omitEmpty: opts == "omitempty"})
+	}
+	return
+}
@@ -0,0 +1,98 @@
+// Package xmlrpc 基于 urlx 的 XML-RPC 客户端
+package xmlrpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/cnk3x/go/urlx"
+)
+
+var (
+	ErrFault    = errors.New("xmlrpc: fault")
+	ErrResponse = errors.New("xmlrpc: invalid response")
+)
+
+// Fault 服务端返回的 fault，errors.Is(err, ErrFault) 为真
+type Fault struct {
+	Code   int    `xmlrpc:"faultCode"`
+	String string `xmlrpc:"faultString"`
+}
+
+func (f *Fault) Error() string {
+	return fmt.Sprintf("xmlrpc: %s (%d)", f.String, f.Code)
+}
+
+func (f *Fault) Unwrap() error {
+	return ErrFault
+}
+
+// Client XML-RPC 客户端
+type Client struct {
+	endpoint string
+	req      *urlx.Request
+}
+
+// New 创建客户端，每次请求以 req 为模板复制，可以在 req 上设置请求头、重试、中间件等，为 nil 时使用 urlx.Default
+func New(endpoint string, req *urlx.Request) *Client {
+	if req == nil {
+		req = urlx.Default(nil)
+	}
+	return &Client{endpoint: endpoint, req: req}
+}
+
+// Call 调用方法，将第一个返回值解码到 out，服务端返回 fault 时为 *Fault
+//
+// 参数和结果的类型对应关系:
+//
+//	int, int64 等整数  <int>、<i4>、<i8>
+//	bool              <boolean>
+//	string            <string>
+//	float64           <double>
+//	time.Time         <dateTime.iso8601>
+//	[]byte            <base64>
+//	切片、数组         <array>
+//	结构体、map        <struct>，结构体字段名以 xmlrpc 标签指定
+//	nil               <nil/>
+func (c *Client) Call(ctx context.Context, method string, out any, params ...any) error {
+	call := &methodCall{Method: method, Params: make([]value, len(params))}
+	for i, p := range params {
+		call.Params[i] = value{p}
+	}
+
+	var resp methodResponse
+	err := c.req.WithContext(ctx).
+		Url(c.endpoint).
+		Method(http.MethodPost).
+		SendXML(call).
+		HeaderWith(urlx.HeaderSet(urlx.HeaderContentType, "text/xml; charset=utf-8")).
+		Process(func(r *http.Response, body io.ReadCloser) error {
+			if r.StatusCode < 200 || r.StatusCode > 299 {
+				defer body.Close()
+				data, _ := io.ReadAll(io.LimitReader(body, 64<<10))
+				return &urlx.StatusError{StatusCode: r.StatusCode, Status: r.Status, Body: data}
+			}
+			if err := urlx.XML(&resp)(r, body); err != nil {
+				return fmt.Errorf("%w: %v", ErrResponse, err)
+			}
+			return nil
+		})
+	if err != nil {
+		return err
+	}
+
+	if resp.Fault != nil {
+		fault := &Fault{}
+		if err = Unmarshal(resp.Fault.v, fault); err != nil {
+			return fmt.Errorf("%w: %v", ErrResponse, err)
+		}
+		return fault
+	}
+	if out == nil || len(resp.Params) == 0 {
+		return nil
+	}
+	return Unmarshal(resp.Params[0].v, out)
+}
@@ -0,0 +1,142 @@
+package xmlrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cnk3x/go/urlx"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if ct := r.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/xml") {
+			t.Errorf("Content-Type = %q", ct)
+		}
+		var call methodCall
+		if err := xml.NewDecoder(r.Body).Decode(&call); err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		rw.Header().Set("Content-Type", "text/xml")
+		switch call.Method {
+		case "echo":
+			// 以同样的编码原样返回全部参数
+			var buf bytes.Buffer
+			_ = xml.NewEncoder(&buf).Encode(&methodCall{Method: "echo", Params: []value{{call.Params}}})
+			_, _ = rw.Write([]byte(`<?xml version="1.0"?><methodResponse><params><param>`))
+			data := buf.String()
+			_, _ = rw.Write([]byte(data[strings.Index(data, "<value>") : strings.LastIndex(data, "</value>")+len("</value>")]))
+			_, _ = rw.Write([]byte(`</param></params></methodResponse>`))
+		case "wp.getPost":
+			_, _ = rw.Write([]byte(`<?xml version="1.0"?>
+<methodResponse><params><param><value><struct>
+  <member><name>post_id</name><value><string>42</string></value></member>
+  <member><name>post_title</name><value>Hello</value></member>
+  <member><name>post_date</name><value><dateTime.iso8601>20220105T08:30:00</dateTime.iso8601></value></member>
+  <member><name>sticky</name><value><boolean>1</boolean></value></member>
+  <member><name>comment_count</name><value><i4>3</i4></value></member>
+  <member><name>terms</name><value><array><data>
+    <value><struct><member><name>name</name><value><string>go</string></value></member></struct></value>
+  </data></array></value></member>
+  <member><name>thumbnail</name><value><base64>aGk=</base64></value></member>
+  <member><name>rating</name><value><double>4.5</double></value></member>
+  <member><name>parent</name><value><nil/></value></member>
+</struct></value></param></params></methodResponse>`))
+		case "down":
+			http.Error(rw, "down", http.StatusBadGateway)
+		default:
+			_, _ = rw.Write([]byte(`<?xml version="1.0"?>
+<methodResponse><fault><value><struct>
+  <member><name>faultCode</name><value><int>-32601</int></value></member>
+  <member><name>faultString</name><value><string>server error. requested method ` + call.Method + ` does not exist.</string></value></member>
+</struct></value></fault></methodResponse>`))
+		}
+	}))
+}
+
+type post struct {
+	ID       string    `xmlrpc:"post_id"`
+	Title    string    `xmlrpc:"post_title"`
+	Date     time.Time `xmlrpc:"post_date"`
+	Sticky   bool      `xmlrpc:"sticky"`
+	Comments int       `xmlrpc:"comment_count"`
+	Terms    []struct {
+		Name string
+	} `xmlrpc:"terms"`
+	Thumbnail []byte  `xmlrpc:"thumbnail"`
+	Rating    float64 `xmlrpc:"rating"`
+	Parent    *post   `xmlrpc:"parent"`
+}
+
+func TestCall(t *testing.T) {
+	ts := newTestServer(t)
+	defer ts.Close()
+	c := New(ts.URL, nil)
+
+	var p post
+	if err := c.Call(context.Background(), "wp.getPost", &p, 1, "admin", "secret", 42); err != nil {
+		t.Fatal(err)
+	}
+	want := post{
+		ID: "42", Title: "Hello", Date: time.Date(2022, 1, 5, 8, 30, 0, 0, time.UTC), Sticky: true, Comments: 3,
+		Terms: []struct{ Name string }{{"go"}}, Thumbnail: []byte("hi"), Rating: 4.5,
+	}
+	if !reflect.DeepEqual(p, want) {
+		t.Fatalf("got %+v, want %+v", p, want)
+	}
+
+	err := c.Call(context.Background(), "missing", nil)
+	var fault *Fault
+	if !errors.Is(err, ErrFault) || !errors.As(err, &fault) || fault.Code != -32601 || !strings.Contains(fault.String, "missing") {
+		t.Fatalf("err = %v", err)
+	}
+
+	var se *urlx.StatusError
+	if err = c.Call(context.Background(), "down", nil); !errors.As(err, &se) || se.StatusCode != http.StatusBadGateway {
+		t.Fatalf("err = %v, want StatusError 502", err)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	ts := newTestServer(t)
+	defer ts.Close()
+	c := New(ts.URL, nil)
+
+	type member struct {
+		Name  string `xmlrpc:"name"`
+		Skip  string `xmlrpc:"-"`
+		Empty string `xmlrpc:"empty,omitempty"`
+	}
+	now := time.Date(2022, 3, 4, 5, 6, 7, 0, time.UTC)
+	var out []any
+	err := c.Call(context.Background(), "echo", &out,
+		int64(1)<<40, true, "a<b", 1.25, now, []byte{0, 1}, []int{1, 2}, map[string]int{"x": 1}, member{Name: "n", Skip: "s"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []any{
+		int64(1) << 40, true, "a<b", 1.25, now, []byte{0, 1}, []any{int64(1), int64(2)},
+		map[string]any{"x": int64(1)}, map[string]any{"name": "n"}, nil,
+	}
+	if !reflect.DeepEqual(out, want) {
+		t.Fatalf("got %#v\nwant %#v", out, want)
+	}
+
+	var n int8
+	if err = Unmarshal(int64(300), &n); !errors.Is(err, ErrResponse) {
+		t.Fatalf("overflow: %v", err)
+	}
+	var m map[string]uint
+	if err = Unmarshal(map[string]any{"a": int64(2)}, &m); err != nil || m["a"] != 2 {
+		t.Fatalf("map: %v %v", m, err)
+	}
+}